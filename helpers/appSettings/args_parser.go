@@ -1,6 +1,7 @@
 package appSettings
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/jessevdk/go-flags"
@@ -10,6 +11,104 @@ import (
 type ExecutionConf struct {
 	MetricTimeout       time.Duration `hidden:"true" long:"timeout" default:"30s" description:"The timeout value used when executing a metric line"`
 	CheckConfigFilePath string        `short:"t" long:"checkconfig" description:"Checks that the supplied configuration file is correct. Returns 0 if it is valid"  `
+	Workers             int           `long:"workers" default:"4" description:"The maximum amount of configuration files evaluated concurrently"`
+}
+
+// DaemonConf options for the long-running daemon mode
+type DaemonConf struct {
+	Interval time.Duration `long:"interval" default:"30s" description:"The interval between two consecutive evaluations of the configuration files"`
+	MaxAge   time.Duration `long:"maxage" default:"90s" description:"The maximum age of the cached evaluation. Older results are re-evaluated before answering a poll"`
+}
+
+// AgentXConf options for the AgentX subagent
+type AgentXConf struct {
+	Socket string        `long:"socket" default:"/var/agentx/master" description:"The address of the AgentX master agent. Either the path of a unix socket or [tcp:<host>:<port>]"`
+	Retry  time.Duration `long:"retry" default:"10s" description:"The time to wait before reconnecting to the master agent once the session is lost"`
+}
+
+// SNMPConf options for the built-in SNMP agent
+type SNMPConf struct {
+	Listen      string   `long:"listen" default:":161" description:"The UDP address the SNMP agent listens on"`
+	Communities []string `long:"community" description:"SNMPv2c community accepted by the agent. Can be given multiple times"`
+	Users       []string `long:"user" description:"SNMPv3 user accepted by the agent, in the format [<name>:<MD5|SHA>:<password>]. Can be given multiple times"`
+}
+
+// StatusConf options for the HTTP status API
+type StatusConf struct {
+	Listen string `long:"listen" description:"Serve the cached evaluation as JSON (and the Prometheus metrics) over HTTP on the given address, either [<host>:<port>] on the loopback interface or [unix:<path>]"`
+}
+
+// MetricsConf options for the Prometheus metrics
+type MetricsConf struct {
+	TextFile string `long:"textfile" description:"Write the Prometheus metrics to the given file after every evaluation, for the textfile collector of node_exporter"`
+}
+
+// StateConf options for the state of the checks kept between the evaluations
+type StateConf struct {
+	File      string `long:"file" default:"/var/lib/lbclient/state.json" description:"The file keeping the recent results of the checks between the evaluations, when the flapping checks are dampened or the slow-start is enabled"`
+	Failures  int    `long:"failures" default:"1" description:"Only consider a check as failing after the given amount of consecutive failures"`
+	Successes int    `long:"successes" default:"1" description:"Only consider a failing check as passing again after the given amount of consecutive successes"`
+}
+
+// Dampening : Checks if the flapping checks are dampened, which requires the state file
+func (s StateConf) Dampening() bool {
+	return s.Failures > 1 || s.Successes > 1
+}
+
+// SlowStartConf options for the slow-start of the nodes returning to service
+type SlowStartConf struct {
+	Period     time.Duration `long:"period" default:"0s" description:"Raise the load during the given period after the node returns to service: after a boot, a change of the roger appstate to production, or a successful evaluation after failures. Disabled by default"`
+	Extra      int           `long:"extra" default:"1000" description:"The load added at the start of the slow-start period, decaying linearly to zero at its end"`
+	UptimeFile string        `hidden:"true" long:"uptime" default:"/proc/uptime" description:"The file giving the uptime of the node"`
+	RogerFile  string        `hidden:"true" long:"roger" default:"/etc/roger/current.yaml" description:"The file giving the roger appstate of the node"`
+}
+
+// Enabled : Checks if the load is raised after the node returns to service, which requires the state file
+func (s SlowStartConf) Enabled() bool {
+	return s.Period > 0
+}
+
+// DrainConf options for the drain of the aliases
+type DrainConf struct {
+	Dir   string `long:"dir" default:"/etc/lbclient" description:"The directory of the drain files: [drain] drains all the aliases, and [drain.<alias>] only the given one. A drain file holds the duration of the drain (e.g. [30m]), counted from its modification time"`
+	Extra int    `long:"extra" default:"1000" description:"The load added at the end of the drain, raised linearly from zero, before the alias is excluded"`
+}
+
+// ExplainCommand options for the [explain] command
+type ExplainCommand struct {
+	Alias     string `long:"alias" description:"Only explain the configuration file of the given alias"`
+	Format    string `long:"format" default:"table" choice:"table" choice:"json" description:"Output format"`
+	KeepGoing bool   `short:"k" long:"keep-going" description:"Keep evaluating the lines after a failure, so that all the problems show up in one run"`
+}
+
+// LintCommand options for the [lint] command
+type LintCommand struct {
+	Format string `long:"format" default:"text" choice:"text" choice:"json" description:"Output format"`
+	Strict bool   `long:"strict" description:"Also fail when only warnings are found"`
+}
+
+// ConvertCommand options for the [convert] command
+type ConvertCommand struct {
+	Format string `long:"format" choice:"conf" choice:"yaml" choice:"json" description:"Output format. Defaults to [conf] for the structured files, and to [yaml] otherwise"`
+	Output string `short:"o" long:"output" description:"Write the converted file to the given path instead of the standard output"`
+	Args   struct {
+		File string `positional-arg-name:"file" description:"Configuration file to convert"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// FmtCommand options for the [fmt] command
+type FmtCommand struct {
+	Check bool `long:"check" description:"Do not rewrite the files, only list the ones that are not formatted and fail if there is any"`
+	Args  struct {
+		Files []string `positional-arg-name:"file" description:"Configuration file to format. Defaults to all the configuration files, their fragments and the files they include"`
+	} `positional-args:"yes"`
+}
+
+// StateCommand options for the [state] command
+type StateCommand struct {
+	Alias  string `long:"alias" description:"Only show or reset the state of the given alias"`
+	Format string `long:"format" default:"table" choice:"table" choice:"json" description:"Output format"`
+	Reset  bool   `long:"reset" description:"Forget the recent results of the checks instead of showing them"`
 }
 
 // Options : Supported application flags
@@ -24,16 +123,91 @@ type Options struct {
 	LbPostFile              string `short:"p" long:"post" description:"Set the default file for the configuration of the ermis communication"`
 	/* Execution specific */
 	ExecutionConfiguration ExecutionConf `group:"exec" namespace:"exec" env-namespace:"exec" description:"Execution specific instructions"`
+	/* Dampening of the flapping checks */
+	StateConfiguration StateConf `group:"state" namespace:"state" env-namespace:"state" description:"Dampening of the flapping checks"`
+	/* Slow-start of the nodes returning to service */
+	SlowStartConfiguration SlowStartConf `group:"slowstart" namespace:"slowstart" env-namespace:"slowstart" description:"Slow-start of the nodes returning to service"`
+	/* Drain of the aliases */
+	DrainConfiguration DrainConf `group:"drain" namespace:"drain" env-namespace:"drain" description:"Drain of the aliases"`
+	/* Daemon specific */
+	Daemon              bool       `long:"daemon" description:"Keep running, evaluate the configuration files periodically and answer the polls received on stdin from the cached results"`
+	DaemonConfiguration DaemonConf `group:"daemon" namespace:"daemon" env-namespace:"daemon" description:"Daemon specific instructions"`
+	/* SNMP */
+	AgentX              bool       `long:"agentx" description:"Register as an AgentX subagent of snmpd and answer its requests from the cached results of the periodic evaluation"`
+	AgentXConfiguration AgentXConf `group:"agentx" namespace:"agentx" env-namespace:"agentx" description:"AgentX subagent specific instructions"`
+	SNMP                bool       `long:"snmp" description:"Answer the SNMP requests directly, without snmpd, from the cached results of the periodic evaluation"`
+	SNMPConfiguration   SNMPConf   `group:"snmp" namespace:"snmp" env-namespace:"snmp" description:"Built-in SNMP agent specific instructions"`
+	/* Status API */
+	StatusConfiguration StatusConf `group:"status" namespace:"status" env-namespace:"status" description:"HTTP status API specific instructions"`
+	/* Prometheus metrics */
+	MetricsConfiguration MetricsConf `group:"metrics" namespace:"metrics" env-namespace:"metrics" description:"Prometheus metrics specific instructions"`
 	/* Misc */
-	Version bool   `short:"v" long:"version" description:"Version of the file"`
-	GData   string `short:"g" long:"gdata" description:"Option needed by the snmp calls"`
-	NData   string `short:"n" long:"ndata" description:"Option needed by the snmp calls"`
+	Version     bool   `short:"v" long:"version" description:"Version of the file"`
+	GData       string `short:"g" long:"gdata" description:"Answer the snmpd [pass] get request for the given OID"`
+	NData       string `short:"n" long:"ndata" description:"Answer the snmpd [pass] getnext request for the given OID"`
+	PassPersist bool   `long:"pass-persist" description:"Speak the snmpd [pass_persist] protocol on stdin/stdout, answering from the cached results of the periodic evaluation"`
+	/* Commands */
+	Explain ExplainCommand `command:"explain" description:"Evaluate the configuration files and print the result of every line"`
+	Lint    LintCommand    `command:"lint" description:"Report the errors and the likely mistakes of all the configuration files, without running anything"`
+	Convert ConvertCommand `command:"convert" description:"Translate a configuration file between the line format and the structured (YAML or JSON) format"`
+	Fmt     FmtCommand     `command:"fmt" description:"Rewrite the configuration files in their canonical form"`
+	State   StateCommand   `command:"state" description:"Show or reset the recent results of the checks kept by the dampening"`
+	// Command is the name of the command given in the arguments, if any
+	Command string `no-flag:"true"`
+}
+
+// LongRunning : Checks if one of the modes that keep the application running was requested
+func (o Options) LongRunning() bool {
+	return o.Daemon || o.PassPersist || o.AgentX || o.SNMP || o.StatusConfiguration.Listen != ""
 }
 
 // ParseApplicationSettings : Helper function to handle the parsing of the @see AppArgs schema against a given slice of
 // arguments in slice format
 func ParseApplicationSettings(args *Options, values []string) error {
 	appSettingsParser := flags.NewParser(args, flags.Default)
+	appSettingsParser.SubcommandsOptional = true
 	_, err := appSettingsParser.ParseArgs(values)
-	return err
+	if appSettingsParser.Active != nil {
+		args.Command = appSettingsParser.Active.Name
+	}
+	if err != nil {
+		return err
+	}
+	return args.validate()
+}
+
+// validate : Checks the values of the options that the parser accepts but the application cannot use
+func (o Options) validate() error {
+	if o.ExecutionConfiguration.Workers <= 0 {
+		return fmt.Errorf("the amount of configuration files evaluated concurrently [--exec.workers=%d] must be positive",
+			o.ExecutionConfiguration.Workers)
+	}
+	if o.DaemonConfiguration.Interval <= 0 {
+		return fmt.Errorf("the interval between the evaluations [--daemon.interval=%s] must be positive",
+			o.DaemonConfiguration.Interval)
+	}
+	if o.DaemonConfiguration.MaxAge <= 0 {
+		return fmt.Errorf("the maximum age of the cached evaluation [--daemon.maxage=%s] must be positive",
+			o.DaemonConfiguration.MaxAge)
+	}
+	if o.StateConfiguration.Failures < 1 {
+		return fmt.Errorf("the amount of consecutive failures [--state.failures=%d] must be at least 1",
+			o.StateConfiguration.Failures)
+	}
+	if o.StateConfiguration.Successes < 1 {
+		return fmt.Errorf("the amount of consecutive successes [--state.successes=%d] must be at least 1",
+			o.StateConfiguration.Successes)
+	}
+	if o.SlowStartConfiguration.Period < 0 {
+		return fmt.Errorf("the slow-start period [--slowstart.period=%s] cannot be negative",
+			o.SlowStartConfiguration.Period)
+	}
+	if o.SlowStartConfiguration.Extra <= 0 {
+		return fmt.Errorf("the load added by the slow-start [--slowstart.extra=%d] must be positive",
+			o.SlowStartConfiguration.Extra)
+	}
+	if o.DrainConfiguration.Extra < 0 {
+		return fmt.Errorf("the load added by the drain [--drain.extra=%d] cannot be negative", o.DrainConfiguration.Extra)
+	}
+	return nil
 }
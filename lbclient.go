@@ -2,11 +2,16 @@ package main
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/jessevdk/go-flags"
 	logger "github.com/sirupsen/logrus"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/snmp"
 )
 
 const (
@@ -47,13 +52,71 @@ func main() {
 			err.Error())
 	}
 
+	// Validate the configuration file, without running any check or load
+	if len(launcher.AppOptions.ExecutionConfiguration.CheckConfigFilePath) != 0 {
+		checkConfig(launcher)
+		os.Exit(0)
+	}
+
+	// Print the evaluation trace of the configuration files
+	if launcher.AppOptions.Command == "explain" {
+		if err = launcher.Explain(os.Stdout); err != nil {
+			logger.Fatalf("A fatal error occurred when attempting to explain the configuration. Error [%s]",
+				err.Error())
+		}
+		os.Exit(0)
+	}
+
+	// Translate a configuration file to another format
+	if launcher.AppOptions.Command == "convert" {
+		if err = launcher.Convert(os.Stdout); err != nil {
+			logger.Fatalf("A fatal error occurred when attempting to convert the configuration file. Error [%s]",
+				err.Error())
+		}
+		os.Exit(0)
+	}
+
+	// Rewrite the configuration files in their canonical form
+	if launcher.AppOptions.Command == "fmt" {
+		formatted, err := launcher.Format(os.Stdout)
+		if err != nil {
+			logger.Fatalf("A fatal error occurred when attempting to format the configuration files. Error [%s]",
+				err.Error())
+		}
+		if !formatted && launcher.AppOptions.Fmt.Check {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Show or reset the recent results of the checks kept by the dampening
+	if launcher.AppOptions.Command == "state" {
+		if err = launcher.State(os.Stdout); err != nil {
+			logger.Fatalf("A fatal error occurred when attempting to access the state file. Error [%s]", err.Error())
+		}
+		os.Exit(0)
+	}
+
+	// Report the problems of all the configuration files
+	if launcher.AppOptions.Command == "lint" {
+		lint(launcher)
+		os.Exit(0)
+	}
+
+	// Keep running and answer the polls from the cached evaluations
+	if launcher.AppOptions.LongRunning() {
+		runDaemon(launcher)
+		os.Exit(0)
+	}
+
 	// Run the launcher
 	err = launcher.Run()
 	if err != nil {
 		logger.Fatalf("A fatal error occurred when attempting to run the application. Error [%s]", err.Error())
 	}
-	if len(launcher.AppOptions.ExecutionConfiguration.CheckConfigFilePath) != 0 {
-		logger.Info("The configuration file is correct")
+	if launcher.AppOptions.GData != "" || launcher.AppOptions.NData != "" {
+		// Answer the snmpd [pass] request
+		printPassAnswer(launcher)
 	} else {
 		// Print the output
 		launcher.PrintOutput(OID)
@@ -64,3 +127,156 @@ func main() {
 		launcher.PostToErmis(launcher.AppOptions.LbPostFile)
 	}
 }
+
+// checkConfig : Logs the diagnostics of the configuration file given with [--checkconfig], and exits with an error if
+// the file is not correct
+func checkConfig(launcher *lbconfig.AppLauncher) {
+	diagnostics, err := launcher.Validate()
+	if err != nil {
+		logger.Fatalf("A fatal error occurred when attempting to validate the configuration file. Error [%s]",
+			err.Error())
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == lbconfig.SeverityError {
+			logger.Error(diagnostic.String())
+		} else {
+			logger.Warn(diagnostic.String())
+		}
+	}
+	if lbconfig.HasErrors(diagnostics) {
+		logger.Fatalf("The configuration file [%s] is not correct",
+			launcher.AppOptions.ExecutionConfiguration.CheckConfigFilePath)
+	}
+	logger.Info("The configuration file is correct")
+}
+
+// lint : Prints the diagnostics of all the configuration files, and exits with an error if there is any error (or any
+// warning, in strict mode)
+func lint(launcher *lbconfig.AppLauncher) {
+	options := launcher.AppOptions.Lint
+	diagnostics := launcher.Lint()
+	if err := lbconfig.WriteDiagnostics(os.Stdout, diagnostics, options.Format); err != nil {
+		logger.Fatalf("A fatal error occurred when attempting to print the diagnostics. Error [%s]", err.Error())
+	}
+	if lbconfig.HasErrors(diagnostics) || (options.Strict && len(diagnostics) != 0) {
+		os.Exit(1)
+	}
+}
+
+// runDaemon : Evaluates the configuration files periodically and answers the polls with the latest cached output.
+// The polls are either received on stdin (plain lines or pass_persist requests), in which case it runs until stdin is
+// closed, or through the enabled listeners, in which case it runs until it is interrupted
+func runDaemon(launcher *lbconfig.AppLauncher) {
+	daemon := lbconfig.NewDaemon(launcher)
+	stop := make(chan struct{})
+	go daemon.Start(stop)
+	defer close(stop)
+
+	oid, _ := snmp.ParseOID(OID)
+	// The SNMP managers (and the master agent, which drops the sessions of the subagents) give up on the answers that
+	// come too late, so the SNMP requests are answered from the cached evaluation, even if it expired, instead of
+	// evaluating the configuration files again. The periodic evaluation keeps it up to date
+	source := func() *snmp.Table {
+		snapshot := daemon.Snapshot()
+		if snapshot == nil {
+			return snmp.NewTable(oid, "", "", nil)
+		}
+		return snmp.NewTable(oid, snapshot.MetricType, snapshot.MetricValue, snapshot.Mappings)
+	}
+
+	listening := false
+	if launcher.AppOptions.AgentX {
+		agent := snmp.NewAgentX(launcher.AppOptions.AgentXConfiguration.Socket, oid, source)
+		agent.Retry = launcher.AppOptions.AgentXConfiguration.Retry
+		go agent.Run(stop)
+		listening = true
+	}
+	if launcher.AppOptions.SNMP {
+		serveSNMP(launcher, source, stop)
+		listening = true
+	}
+	if launcher.AppOptions.StatusConfiguration.Listen != "" {
+		serveStatus(launcher, daemon, stop)
+		listening = true
+	}
+
+	if launcher.AppOptions.PassPersist {
+		if err := snmp.ServePassPersist(os.Stdin, os.Stdout, source); err != nil {
+			logger.Fatalf("A fatal error occurred when speaking the pass_persist protocol. Error [%s]", err.Error())
+		}
+	} else if !listening {
+		if err := daemon.Serve(os.Stdin, os.Stdout, OID); err != nil {
+			logger.Fatalf("A fatal error occurred when attempting to answer the polls. Error [%s]", err.Error())
+		}
+	} else {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+		logger.Infof("Received the signal [%s]. Stopping...", <-interrupt)
+	}
+}
+
+// serveSNMP : Starts the built-in SNMP agent, which answers the requests until the stop channel is closed
+func serveSNMP(launcher *lbconfig.AppLauncher, source snmp.TableSource, stop <-chan struct{}) {
+	options := launcher.AppOptions.SNMPConfiguration
+	agent := snmp.NewAgent(source)
+	agent.Communities = options.Communities
+	for _, user := range options.Users {
+		if err := agent.AddUser(user); err != nil {
+			logger.Fatalf("A fatal error occurred when attempting to add the SNMPv3 user. Error [%s]", err.Error())
+		}
+	}
+	if len(agent.Communities) == 0 && len(agent.Users) == 0 {
+		logger.Fatal("The SNMP agent needs at least one community or SNMPv3 user")
+	}
+
+	conn, err := net.ListenPacket("udp", options.Listen)
+	if err != nil {
+		logger.Fatalf("A fatal error occurred when attempting to listen on [%s]. Error [%s]", options.Listen, err.Error())
+	}
+	go func() {
+		<-stop
+		_ = conn.Close()
+	}()
+	go func() {
+		logger.Infof("Answering the SNMP requests on [%s]", conn.LocalAddr())
+		if err := agent.Serve(conn); err != nil {
+			logger.WithError(err).Info("Stopped answering the SNMP requests")
+		}
+	}()
+}
+
+// serveStatus : Starts the HTTP status API, which serves the cached evaluation until the stop channel is closed
+func serveStatus(launcher *lbconfig.AppLauncher, daemon *lbconfig.Daemon, stop <-chan struct{}) {
+	address := launcher.AppOptions.StatusConfiguration.Listen
+	listener, err := lbconfig.ListenStatus(address)
+	if err != nil {
+		logger.Fatalf("A fatal error occurred when attempting to listen on [%s]. Error [%s]", address, err.Error())
+	}
+	server := &http.Server{Handler: lbconfig.NewStatusHandler(daemon)}
+	go func() {
+		<-stop
+		_ = server.Close()
+	}()
+	go func() {
+		logger.Infof("Serving the status API on [%s]", address)
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			logger.WithError(err).Error("Stopped serving the status API")
+		}
+	}()
+}
+
+// printPassAnswer : Prints the answer to the snmpd [pass] get (-g) or getnext (-n) request. Nothing is printed if the
+// requested OID is not served
+func printPassAnswer(launcher *lbconfig.AppLauncher) {
+	oid, _ := snmp.ParseOID(OID)
+	table := snmp.NewTable(oid, launcher.MetricType, launcher.MetricValue, launcher.Mappings())
+	var answer string
+	if launcher.AppOptions.GData != "" {
+		answer = snmp.PassAnswer(table, "get", launcher.AppOptions.GData)
+	} else {
+		answer = snmp.PassAnswer(table, "getnext", launcher.AppOptions.NData)
+	}
+	if answer != "NONE\n" {
+		fmt.Print(answer)
+	}
+}
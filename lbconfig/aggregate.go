@@ -0,0 +1,127 @@
+package lbconfig
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+)
+
+// aggregator : Combines the results of the load lines of a configuration file into its load, with one of the
+// @see config.AggregateMethods. The weights only matter to the weighted average, and default to 1. The penalties of the
+// failing soft checks are added to the aggregated load
+type aggregator struct {
+	method    string
+	values    []int
+	weights   []float64
+	penalties []int
+}
+
+// newAggregator : Creates the aggregator of the loads of a configuration file. The loads are added up, unless the file
+// has an [aggregate] statement
+func newAggregator(file *config.File) *aggregator {
+	if file.Aggregate == nil {
+		return &aggregator{method: config.AggregateSum}
+	}
+	return &aggregator{method: file.Aggregate.Method}
+}
+
+// add : Adds the result of a load line
+func (a *aggregator) add(value int, weight float64) {
+	if weight <= 0 {
+		weight = 1
+	}
+	a.values, a.weights = append(a.values, value), append(a.weights, weight)
+}
+
+// penalize : Adds the penalty of a failing soft check
+func (a *aggregator) penalize(penalty int) {
+	a.penalties = append(a.penalties, penalty)
+}
+
+// value : Returns the load of the lines added so far: their aggregation and the penalties
+func (a *aggregator) value() int {
+	return a.aggregate() + a.penalty()
+}
+
+// aggregate : Returns the aggregation of the load lines added so far, or zero if there are none
+func (a *aggregator) aggregate() int {
+	if len(a.values) == 0 {
+		return 0
+	}
+	switch a.method {
+	case config.AggregateMax, config.AggregateMin:
+		result := a.values[0]
+		for _, value := range a.values[1:] {
+			if a.method == config.AggregateMax && value > result || a.method == config.AggregateMin && value < result {
+				result = value
+			}
+		}
+		return result
+	case config.AggregateAvg, config.AggregateWeighted:
+		total, weights := 0., 0.
+		for i, value := range a.values {
+			weight := 1.
+			if a.method == config.AggregateWeighted {
+				weight = a.weights[i]
+			}
+			total, weights = total+weight*float64(value), weights+weight
+		}
+		return int(math.Round(total / weights))
+	}
+	total := 0
+	for _, value := range a.values {
+		total += value
+	}
+	return total
+}
+
+// penalty : Returns the sum of the penalties added so far
+func (a *aggregator) penalty() int {
+	total := 0
+	for _, penalty := range a.penalties {
+		total += penalty
+	}
+	return total
+}
+
+// formula : Returns the formula of the given final load, e.g. [max(12, 30) + penalties(100) = 130], or an empty string
+// if there is no load line nor penalty. If defaulted is set, the load lines were replaced by the generic load
+func (a *aggregator) formula(load int, defaulted bool) string {
+	if len(a.values) == 0 && len(a.penalties) == 0 {
+		return ""
+	}
+	terms := make([]string, len(a.values))
+	for i, value := range a.values {
+		terms[i] = strconv.Itoa(value)
+	}
+	var expressions []string
+	switch {
+	case defaulted:
+		expressions = append(expressions, fmt.Sprintf("default(%d)", load-a.penalty()))
+	case len(a.values) == 0:
+	case a.method == config.AggregateMax || a.method == config.AggregateMin || a.method == config.AggregateAvg:
+		expressions = append(expressions, a.method+"("+strings.Join(terms, ", ")+")")
+	case a.method == config.AggregateWeighted:
+		weights := 0.
+		for i, weight := range a.weights {
+			terms[i] = strconv.FormatFloat(weight, 'g', -1, 64) + "*" + terms[i]
+			weights += weight
+		}
+		// The sum of the weights is rounded, so that e.g. [0.7 + 0.3] shows as [1]
+		expressions = append(expressions, fmt.Sprintf("(%s) / %s", strings.Join(terms, " + "),
+			strconv.FormatFloat(weights, 'g', 12, 64)))
+	default:
+		expressions = append(expressions, strings.Join(terms, " + "))
+	}
+	if len(a.penalties) != 0 {
+		penalties := make([]string, len(a.penalties))
+		for i, penalty := range a.penalties {
+			penalties[i] = strconv.Itoa(penalty)
+		}
+		expressions = append(expressions, "penalties("+strings.Join(penalties, ", ")+")")
+	}
+	return fmt.Sprintf("%s = %d", strings.Join(expressions, " + "), load)
+}
@@ -0,0 +1,65 @@
+package lbconfig
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+)
+
+// resultCache : Per-run cache of the results of the action lines, shared by all the configuration mappings evaluated
+// in the same run. Concurrent evaluations of the same line wait for the first one instead of running it again
+type resultCache struct {
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry : Result of an action line. The done channel is closed once the result is available
+type cacheEntry struct {
+	done  chan struct{}
+	value int
+	err   error
+}
+
+// newResultCache : Factory-pattern function that creates and returns a new @see resultCache struct instance pointer
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]*cacheEntry)}
+}
+
+// get : Returns the cached result for the given key, or calls the given function and caches its result. The returned
+// flag tells if the result was served from the cache
+func (c *resultCache) get(key string, f func() (int, error)) (int, error, bool) {
+	c.mutex.Lock()
+	entry, found := c.entries[key]
+	if !found {
+		entry = &cacheEntry{done: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	c.mutex.Unlock()
+
+	if found {
+		<-entry.done
+		return entry.value, entry.err, true
+	}
+	entry.value, entry.err = f()
+	close(entry.done)
+	return entry.value, entry.err, false
+}
+
+// cacheKey : Returns the key under which the result of an action line run with the given timeout is cached. The
+// spacing of the arguments is normalised, and the inputs that depend on the alias are added for the actions that use
+// them. The timeout is part of the key, since a line that timed out may succeed with a longer timeout
+func cacheKey(action *config.Action, timeout time.Duration, aliasDependent bool, aliasNames []string,
+	isDefault bool) string {
+	key := action.Kind + "\x00" + action.Keyword + "\x00" + strings.Join(strings.Fields(action.Args), " ") + "\x00" +
+		timeout.String()
+	if aliasDependent {
+		if isDefault || len(aliasNames) == 0 {
+			key += "\x00[default]"
+		} else {
+			key += "\x00" + aliasNames[0]
+		}
+	}
+	return key
+}
@@ -1,6 +1,7 @@
 package checks
 
 import (
+	"context"
 	"os"
 
 	logger "github.com/sirupsen/logrus"
@@ -12,7 +13,7 @@ const afsDir = "/afs/cern.ch/user/"
 type AFS struct{}
 
 // Run : Runs the AFS : CLI implementation function
-func (afs AFS) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
+func (afs AFS) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
 	contextLogger.Debug("Checking the that AFS directory is accessible...")
 	if _, err := os.Stat(afsDir); os.IsNotExist(err) {
 		contextLogger.Error("AFS directory does not exist")
@@ -1,9 +1,9 @@
 package checks
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
-	"regexp"
 	"strings"
 	"syscall"
 
@@ -22,15 +22,11 @@ const (
 	CommandNotExecutable = 126
 )
 
-func (command Command) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
-	cmd, _ := regexp.Compile("(?i)(^check[ ]+command)")
-	line := args[0].(string)
-	found := cmd.Split(line, -1)
-
-	if len(found) > 1 {
-		usrCmd := strings.TrimSpace(found[1])
+func (command Command) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
+	usrCmd := strings.TrimSpace(args[0].(string))
+	if len(usrCmd) != 0 {
 		contextLogger.Tracef("Attempting to run command [%s]", usrCmd)
-		out, err, stderr := runner.RunCommand(usrCmd, true, 0)
+		out, err, stderr := runner.RunCommandContext(ctx, "command", usrCmd, true)
 		if err != nil {
 			contextLogger.Errorf("The command [%s] failed. Error [%v] Stderr[%v]", usrCmd, err, stderr)
 			if exitErr, ok := err.(*exec.ExitError); ok {
@@ -52,5 +48,36 @@ func (command Command) Run(contextLogger *logger.Entry, args ...interface{}) (in
 		return 1, nil
 	}
 
-	return -1, fmt.Errorf("there was no command to execute in the line [%s]", line)
+	return -1, fmt.Errorf("there was no command to execute")
+}
+
+// shellBuiltins : Commands run by [bash] itself, which are not looked up in the PATH
+var shellBuiltins = map[string]bool{"[": true, "[[": true, "test": true, "true": true, "false": true, "echo": true,
+	"exit": true, "cd": true, "source": true, ".": true, "!": true, "if": true, "printf": true, "read": true}
+
+// ValidateArguments : Checks that there is a command. The commands that cannot be found are only reported as warnings,
+// since they may be installed later
+func (command Command) ValidateArguments(args string) ([]string, error) {
+	program := command.Program(args)
+	if len(program) == 0 {
+		return nil, fmt.Errorf("there was no command to execute")
+	}
+	if shellBuiltins[program] || strings.ContainsAny(program, "$`(") {
+		return nil, nil
+	}
+	if _, err := exec.LookPath(program); err != nil {
+		return []string{fmt.Sprintf("the command [%s] cannot be run: %v", program, err)}, nil
+	}
+	return nil, nil
+}
+
+// Program : Returns the program run by the command, skipping the environment variables given to it, or an empty
+// string if there is none
+func (command Command) Program(args string) string {
+	for _, field := range strings.Fields(args) {
+		if !strings.Contains(field, "=") {
+			return field
+		}
+	}
+	return ""
 }
@@ -1,6 +1,7 @@
 package checks
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,18 +11,38 @@ import (
 
 type MetricConstant struct{}
 
-func (mc MetricConstant) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
-	toParseRaw := strings.Split(args[0].(string), " ")
-	if len(toParseRaw) < 3 {
-		return -1, fmt.Errorf("the constant metric [%v] does not have the correct syntax", args[0])
-	}
-	toParse := toParseRaw[2]
-	contextLogger.Debugf("Attempting to parse constant metric [%s]", toParse)
-	f, err := strconv.ParseFloat(toParse, 32)
+func (mc MetricConstant) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
+	contextLogger.Debugf("Attempting to parse constant metric [%s]", args[0])
+	f, err := mc.parse(args[0].(string))
 	if err != nil {
-		return -1, fmt.Errorf("the supplied constant is not a number")
+		return -1, err
 	}
 	contextLogger.Debugf("Successfully parsed the constant [%v]...", f)
 
 	return int(f), nil
 }
+
+// ValidateArguments : Checks that the argument is a number
+func (mc MetricConstant) ValidateArguments(args string) ([]string, error) {
+	_, err := mc.parse(args)
+	return nil, err
+}
+
+// CanBeNegative : Tells if the constant is negative
+func (mc MetricConstant) CanBeNegative(args string) bool {
+	f, err := mc.parse(args)
+	return err == nil && f < 0
+}
+
+// parse : Parses the single argument of the constant
+func (mc MetricConstant) parse(args string) (float64, error) {
+	toParseRaw := strings.Fields(args)
+	if len(toParseRaw) != 1 {
+		return -1, fmt.Errorf("the constant metric [%v] does not have the correct syntax", args)
+	}
+	f, err := strconv.ParseFloat(toParseRaw[0], 32)
+	if err != nil {
+		return -1, fmt.Errorf("the supplied constant is not a number")
+	}
+	return f, nil
+}
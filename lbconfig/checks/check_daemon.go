@@ -2,6 +2,7 @@ package checks
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/network"
 )
 
@@ -47,7 +49,7 @@ type condFilePair struct {
 	filepath string
 }
 
-func (daemon DaemonListening) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
+func (daemon DaemonListening) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
 	daemon.contextLogger = contextLogger
 	metric := args[0].(string)
 
@@ -64,6 +66,24 @@ func (daemon DaemonListening) Run(contextLogger *logger.Entry, args ...interface
 	return daemon.isListening()
 }
 
+// ValidateArguments : Checks the JSON of the daemon check, without looking at the listening sockets
+func (daemon DaemonListening) ValidateArguments(args string) ([]string, error) {
+	daemon.contextLogger = logger.NewEntry(logger.StandardLogger())
+	return nil, daemon.processMetricLine(args)
+}
+
+// FormatArguments : Returns the daemon JSON in its canonical form
+func (daemon DaemonListening) FormatArguments(args string) (string, error) {
+	if len(strings.TrimSpace(args)) == 0 {
+		return "", nil
+	}
+	value, err := config.DecodeJSON(args)
+	if err != nil {
+		return "", err
+	}
+	return config.CanonicalJSON(value)
+}
+
 // parseMetricLineJSON : parse a given json Metric line into the expected schema
 func (daemon *DaemonListening) parseMetricLineJSON(line string) (err error) {
 	if len(line) <= 2 {
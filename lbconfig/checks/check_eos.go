@@ -2,6 +2,7 @@ package checks
 
 import (
 	"bufio"
+	"context"
 	"io"
 	"os"
 	"os/exec"
@@ -18,17 +19,17 @@ const testCmdBase = "/usr/bin/eosxd get eos.mgmurl "
 
 type EOS struct{}
 
-func (eos EOS) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
+func (eos EOS) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
 
 	f, err := os.Open(mountsProcFile)
 	if err != nil {
 		return -1, err
 	}
 	defer func() { err = f.Close() }()
-	return DoEOSCheck(f, testCmdBase, contextLogger)
+	return DoEOSCheck(ctx, f, testCmdBase, contextLogger)
 }
 
-func DoEOSCheck(filehandle io.Reader, baseCmd string, contextLogger *logger.Entry) (int, error) {
+func DoEOSCheck(ctx context.Context, filehandle io.Reader, baseCmd string, contextLogger *logger.Entry) (int, error) {
 	scanner := bufio.NewScanner(filehandle)
 	contextLogger.Trace("Checking the mount entries...")
 	eosEntry, _ := regexp.Compile("^[0-9A-Za-z_-]+ (/eos/[0-9A-Za-z_-]+|/eos/[0-9A-Za-z_-]+/[0-9A-Za-z_-]+) fuse .*")
@@ -38,7 +39,7 @@ func DoEOSCheck(filehandle io.Reader, baseCmd string, contextLogger *logger.Entr
 		if len(match) > 0 {
 			usrCmd := baseCmd + match[1]
 			contextLogger.Tracef("Attempting to run command [%s]", usrCmd)
-			out, err, stderr := runner.RunCommand(usrCmd, true, 0)
+			out, err, stderr := runner.RunCommandContext(ctx, "eosxd", usrCmd, true)
 			if err != nil {
 				contextLogger.Errorf("The command [%s] failed. Error [%v] Stderr[%v]", usrCmd, err, stderr)
 				if stderr != "" {
@@ -1,6 +1,7 @@
 package checks
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -10,7 +11,7 @@ import (
 type NoLogin struct {
 }
 
-func (nl NoLogin) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
+func (nl NoLogin) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
 	// Abort execution if the caller does not fulfill the contract
 	if args == nil || len(args) < 2 {
 		return -1, fmt.Errorf("wrong number or arguments supplied. Please supply an alias name [string] and " +
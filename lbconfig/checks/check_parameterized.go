@@ -1,6 +1,7 @@
 package checks
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -8,9 +9,20 @@ import (
 	"github.com/Knetic/govaluate"
 	logger "github.com/sirupsen/logrus"
 	param "gitlab.cern.ch/lb-experts/golbclient/lbconfig/checks/parameterized"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/parser"
 )
 
+var (
+	// legacyMetric : Metric in the underscore syntax, e.g. [_20003] or [_13163:2]
+	legacyMetric = regexp.MustCompile(`_([0-9]+(?:[:][0-9]+)?)`)
+	// legacyComparison : Comparison written with a single equals sign, e.g. [[13163] = 1]
+	legacyComparison = regexp.MustCompile(`([]0-9][ ]*)[=]([ ]*[0-9\[])`)
+	// verbatimExpression : Parts of an expression that are not formatted: the metrics in the bracket syntax and the
+	// variables
+	verbatimExpression = regexp.MustCompile(`\[[^\[\]]*]|\$\{[^}]*}`)
+)
+
 type ParamCheckType = string
 
 const (
@@ -26,54 +38,24 @@ func (g ParamCheck) isAlarm() bool {
 	return strings.TrimSpace(strings.ToLower(g.Type)) == ALARM
 }
 
-func (g ParamCheck) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
-	var rVal interface{}
-	line := args[0].(string)
-	isCheck := strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "check")
+func (g ParamCheck) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
+	rawExpression := args[0].(string)
 
 	// Abort if no Impl was given during the instancing of the ParamCheck struct
 	if g.Impl == nil {
 		return -1, fmt.Errorf("expected a Impl of check to be given, please see the contract [Parameterized]")
 	}
 
-	isLoad := strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "load")
-	// Log
-	contextLogger.Debugf("Adding [%s] metric [%s]", g.Impl.Name(), line)
-
-	// Support unintentional errors => e.g., [loadcheck collectd], panics if the regex cannot be compiled
-	found := regexp.MustCompile("(?i)(((check)( )+(collectd_alarms))|(check|load)( )+(collectd|lemon))").Split(strings.TrimSpace(line), -1)
-
-	// Found the correct syntax
-	if len(found) != 2 || (!isCheck && !isLoad) {
-		return -1, fmt.Errorf("incorrect syntax specified at [%s]. Please use (`load` or `check` `<cli>`)", line)
-	}
-
-	rawExpression := found[1]
-	// Log
-	contextLogger.Tracef("Found expression [%s]", rawExpression)
-
-	// If no expression was given, fail the whole expression
-	if len(strings.TrimSpace(rawExpression)) == 0 {
-		return -1, fmt.Errorf("detected a (check|load) without metrics. Returning [%v]", rVal)
-	}
-
-	var metrics []string
-	if !g.isAlarm() {
-		// Backwards compatible (remove unnecessary underscores from the expression)
-		g.compatibilityProcess(contextLogger, &rawExpression)
-
-		// Discover all the metrics found in the expression
-		metrics = regexp.MustCompile(`\[([^\[\]]*)]`).FindAllString(rawExpression, -1)
-	} else {
-		// Extract everything between curly brackets (JSON) and send it down to the impl
-		metrics = []string{regexp.MustCompile(`\[.*]`).FindString(rawExpression)}
+	rawExpression, metrics, err := g.prepare(contextLogger, rawExpression)
+	if err != nil {
+		return -1, err
 	}
 
 	contextLogger.Tracef("Found metrics [%v], len [%d]", metrics, len(metrics))
 	parameters := make(map[string]interface{}, len(metrics))
 
 	// Run command with a list of all the metrics found and return a key/value map
-	err := g.Impl.Run(contextLogger.WithField("TYPE", strings.ToUpper(g.Impl.Name())), metrics, &parameters)
+	err = g.Impl.Run(ctx, contextLogger.WithField("TYPE", strings.ToUpper(g.Impl.Name())), metrics, &parameters)
 	if err != nil {
 		return -1, err
 	}
@@ -102,6 +84,101 @@ func (g ParamCheck) Run(contextLogger *logger.Entry, args ...interface{}) (int,
 	return intResult, nil
 }
 
+// ValidateArguments : Checks the syntax of the expression (or of the alarms), without running any CLI
+func (g ParamCheck) ValidateArguments(args string) ([]string, error) {
+	contextLogger := logger.NewEntry(logger.StandardLogger())
+	rawExpression, metrics, err := g.prepare(contextLogger, args)
+	if err != nil {
+		return nil, err
+	}
+	if g.isAlarm() {
+		if validator, ok := g.Impl.(param.Validator); ok {
+			return nil, validator.Validate(metrics)
+		}
+		return nil, nil
+	}
+	_, err = govaluate.NewEvaluableExpression(rawExpression)
+	return nil, err
+}
+
+// CanBeNegative : Tells if the expression subtracts or negates a value, in which case it can return a negative value
+// even though the metrics are positive
+func (g ParamCheck) CanBeNegative(args string) bool {
+	if g.isAlarm() {
+		return false
+	}
+	rawExpression, _, err := g.prepare(logger.NewEntry(logger.StandardLogger()), args)
+	if err != nil {
+		return false
+	}
+	expression, err := govaluate.NewEvaluableExpression(rawExpression)
+	if err != nil {
+		return false
+	}
+	for _, token := range expression.Tokens() {
+		if (token.Kind == govaluate.PREFIX || token.Kind == govaluate.MODIFIER) && token.Value == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatArguments : Returns the canonical form of the expression: the metrics in the bracket syntax (e.g. [[20003]]
+// instead of [_20003]), [==] for the comparisons and single spaces. The alarms are written in canonical JSON
+func (g ParamCheck) FormatArguments(args string) (string, error) {
+	if g.isAlarm() {
+		value, err := config.DecodeJSON(args)
+		if err != nil {
+			return "", err
+		}
+		return config.CanonicalJSON(value)
+	}
+	var formatted strings.Builder
+	last := 0
+	// The metrics already in the bracket syntax and the variables are kept as written
+	for _, verbatim := range verbatimExpression.FindAllStringIndex(args, -1) {
+		formatted.WriteString(formatExpression(args[last:verbatim[0]]))
+		formatted.WriteString(args[verbatim[0]:verbatim[1]])
+		last = verbatim[1]
+	}
+	formatted.WriteString(formatExpression(args[last:]))
+	expression := strings.Join(strings.Fields(formatted.String()), " ")
+	expression = legacyComparison.ReplaceAllString(expression, "$1==$2")
+	if _, _, err := g.prepare(logger.NewEntry(logger.StandardLogger()), expression); err != nil {
+		return "", err
+	}
+	return expression, nil
+}
+
+// formatExpression : Converts the metrics of a part of an expression from the underscore syntax to the bracket syntax
+func formatExpression(part string) string {
+	return legacyMetric.ReplaceAllString(part, "[$1]")
+}
+
+// prepare : Returns the expression, in the current syntax, and the metrics it needs
+func (g ParamCheck) prepare(contextLogger *logger.Entry, rawExpression string) (string, []string, error) {
+	// Log
+	contextLogger.Tracef("Found expression [%s]", rawExpression)
+
+	// If no expression was given, fail the whole expression
+	if len(strings.TrimSpace(rawExpression)) == 0 {
+		return "", nil, fmt.Errorf("detected a (check|load) without metrics")
+	}
+
+	var metrics []string
+	if !g.isAlarm() {
+		// Backwards compatible (remove unnecessary underscores from the expression)
+		g.compatibilityProcess(contextLogger, &rawExpression)
+
+		// Discover all the metrics found in the expression
+		metrics = regexp.MustCompile(`\[([^\[\]]*)]`).FindAllString(rawExpression, -1)
+	} else {
+		// Extract everything between curly brackets (JSON) and send it down to the impl
+		metrics = []string{regexp.MustCompile(`\[.*]`).FindString(rawExpression)}
+	}
+	return rawExpression, metrics, nil
+}
+
 // compatibilityProcess : Processes the metric line so that all the metrics found (_metric) are ported to the new format ([metric])
 func (g ParamCheck) compatibilityProcess(contextLogger *logger.Entry, metric *string) {
 	contextLogger.Tracef("Processing metric [%s]", *metric)
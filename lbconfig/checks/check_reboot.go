@@ -1,6 +1,7 @@
 package checks
 
 import (
+	"context"
 	"os"
 
 	logger "github.com/sirupsen/logrus"
@@ -9,7 +10,7 @@ import (
 type Reboot struct {
 }
 
-func (rb Reboot) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
+func (rb Reboot) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
 
 	file := "/run/systemd/shutdown/scheduled"
 	_, err := os.Stat(file)
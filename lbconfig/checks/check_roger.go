@@ -2,6 +2,7 @@ package checks
 
 import (
 	"bufio"
+	"context"
 	"os"
 	"regexp"
 
@@ -13,17 +14,16 @@ const rogerCurrentFile = "/etc/roger/current.yaml"
 type RogerState struct {
 }
 
-func (rogerState RogerState) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
-
-	f, err := os.Open(rogerCurrentFile)
+// ReadRogerAppstate : Returns the roger appstate of the node, from the given roger facts file
+func ReadRogerAppstate(path string) (string, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return -1, err
+		return "", err
 	}
-	defer func() { err = f.Close() }()
+	defer f.Close()
 
 	scanner := bufio.NewScanner(f)
 	myState := ""
-	contextLogger.Trace("Checking the roger facts...")
 	state, _ := regexp.Compile("^appstate: *([^ \t\n]+)")
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -32,10 +32,25 @@ func (rogerState RogerState) Run(contextLogger *logger.Entry, args ...interface{
 			myState = match[1]
 		}
 	}
+	return myState, scanner.Err()
+}
+
+// RogerInProduction : Checks if the given roger appstate lets the node serve the alias
+func RogerInProduction(appstate string) bool {
+	return appstate == "production" || appstate == "ignore_roger"
+}
+
+func (rogerState RogerState) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
+
+	contextLogger.Trace("Checking the roger facts...")
+	myState, err := ReadRogerAppstate(rogerCurrentFile)
+	if err != nil {
+		return -1, err
+	}
 
 	contextLogger.Tracef("Roger appstate [%s]", myState)
 
-	if myState == "production" || myState == "ignore_roger" {
+	if RogerInProduction(myState) {
 		return 1, nil
 	}
 
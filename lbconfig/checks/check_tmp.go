@@ -1,6 +1,7 @@
 package checks
 
 import (
+	"context"
 	"syscall"
 
 	logger "github.com/sirupsen/logrus"
@@ -14,7 +15,7 @@ const (
 type TmpFull struct {
 }
 
-func (tmpFull TmpFull) Run(contextLogger *logger.Entry, args ...interface{}) (int, error) {
+func (tmpFull TmpFull) Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error) {
 	var stat syscall.Statfs_t
 	err := syscall.Statfs("/tmp", &stat)
 	if err != nil {
@@ -1,8 +1,17 @@
 package param
 
-import logger "github.com/sirupsen/logrus"
+import (
+	"context"
+
+	logger "github.com/sirupsen/logrus"
+)
 
 type Parameterized interface {
-	Run(contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error
+	Run(ctx context.Context, contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error
 	Name() string
 }
+
+// Validator : Optional interface of the implementations that can validate their metrics without running any CLI
+type Validator interface {
+	Validate(metrics []string) error
+}
@@ -1,6 +1,7 @@
 package param
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -19,7 +20,7 @@ func (ci CollectdImpl) Name() string {
 }
 
 // Run : Runs the [collectdctl] for the found metric's list and populates the expression [valueList] with the values fetched from the CLI.
-func (ci CollectdImpl) Run(contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error {
+func (ci CollectdImpl) Run(ctx context.Context, contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error {
 	if len(ci.CommandPath) == 0 {
 		ci.CommandPath = "/usr/bin/collectdctl"
 	}
@@ -54,7 +55,7 @@ func (ci CollectdImpl) Run(contextLogger *logger.Entry, metrics []string, valueL
 		}
 
 		contextLogger.Debugf("Running the [collectd] path [%s] cli for the metric [%s]", ci.CommandPath, metricName)
-		rawOutput, err, stderr := runner.Run(ci.CommandPath, true, 0, "getval", metric)
+		rawOutput, err, stderr := runner.RunContext(ctx, ci.CommandPath, true, "getval", metric)
 		contextLogger.Tracef("Raw output from [collectdctl] [%v] Stderr[%v]", rawOutput, stderr)
 		if err != nil {
 			return fmt.Errorf("failed to run the [collectd] cli with the error [%s]", err.Error())
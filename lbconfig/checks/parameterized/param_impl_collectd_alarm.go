@@ -1,6 +1,7 @@
 package param
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -27,37 +28,23 @@ func (ci CollectdAlarmImpl) Name() string {
 }
 
 // Run : Runs the [collectdctl] for the found metric's list and populates the expression [valueList] with the values fetched from the CLI.
-func (ci CollectdAlarmImpl) Run(contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error {
+func (ci CollectdAlarmImpl) Run(ctx context.Context, contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error {
 	if len(ci.CommandPath) == 0 {
 		ci.CommandPath = "/usr/bin/collectdctl"
 	}
 
-	// Parse the state line into the schema struct
-	parsingContainer := make([]map[string]interface{}, 0)
-	err := json.Unmarshal([]byte(metrics[0]), &parsingContainer)
-	if err != nil || len(parsingContainer) == 0 {
-		return fmt.Errorf(`could not parse ['%s']. Error [%v]`, []byte(metrics[0]), err)
-	}
-
 	// Only run collectdctl for the user defined states
-	userRequiredStates := make(map[string]interface{})
-	for metric, rawStates := range parsingContainer[0] {
-		contextLogger.Tracef("Processing metric [%s] with desired states [%v]...", metric, rawStates)
-		requiredStates, err := ci.parseStates(rawStates)
-		if err != nil {
-			return err
-		}
-
-		for _, state := range requiredStates {
-			userRequiredStates[state] = nil
-		}
+	parsingContainer, userRequiredStates, err := ci.requiredStates(contextLogger, metrics[0])
+	if err != nil {
+		return err
 	}
 
 	// Run the CLI for each state found
 	if ci.cache == nil {
 		// Initialize the map
 		ci.cache = make(map[string]string)
-		resultsCh := make(chan error)
+		// Buffered, so that the remaining lookups can finish once the first error is returned
+		resultsCh := make(chan error, len(userRequiredStates))
 
 		contextLogger.Tracef("No cache found for previous [collectd] alarm cli [%s]. Running the [collectd] cli...",
 			ci.CommandPath)
@@ -68,10 +55,10 @@ func (ci CollectdAlarmImpl) Run(contextLogger *logger.Entry, metrics []string, v
 		for alarmState := range userRequiredStates {
 			go func(state string) {
 				contextLogger.Debugf("Running the [collectd] alarm cli [%s] for the state [%s]...", ci.CommandPath, state)
-				rawOutput, err, stderr := runner.Run(
+				rawOutput, err, stderr := runner.RunContext(
+					ctx,
 					ci.CommandPath,
 					true,
-					0,
 					"listval",
 					fmt.Sprintf("state=%s", state))
 
@@ -130,6 +117,38 @@ func (ci CollectdAlarmImpl) Run(contextLogger *logger.Entry, metrics []string, v
 	return nil
 }
 
+// Validate : Checks the syntax of the alarms, without running the [collectdctl] CLI
+func (ci CollectdAlarmImpl) Validate(metrics []string) error {
+	_, _, err := ci.requiredStates(logger.NewEntry(logger.StandardLogger()), metrics[0])
+	return err
+}
+
+// requiredStates : Parses the alarms (a JSON list with the desired states of each metric). Returns the parsed alarms
+// and the set of all the states
+func (ci CollectdAlarmImpl) requiredStates(contextLogger *logger.Entry, metric string) ([]map[string]interface{},
+	map[string]interface{}, error) {
+	// Parse the state line into the schema struct
+	parsingContainer := make([]map[string]interface{}, 0)
+	err := json.Unmarshal([]byte(metric), &parsingContainer)
+	if err != nil || len(parsingContainer) == 0 {
+		return nil, nil, fmt.Errorf(`could not parse ['%s']. Error [%v]`, []byte(metric), err)
+	}
+
+	userRequiredStates := make(map[string]interface{})
+	for metric, rawStates := range parsingContainer[0] {
+		contextLogger.Tracef("Processing metric [%s] with desired states [%v]...", metric, rawStates)
+		requiredStates, err := ci.parseStates(rawStates)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, state := range requiredStates {
+			userRequiredStates[state] = nil
+		}
+	}
+	return parsingContainer, userRequiredStates, nil
+}
+
 func (ci CollectdAlarmImpl) parseStates(states interface{}) (out []string, err error) {
 	switch v := states.(type) {
 	case []interface{}:
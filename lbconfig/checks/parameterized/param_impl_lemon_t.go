@@ -1,6 +1,7 @@
 package param
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -26,7 +27,7 @@ func (li LemonImpl) Name() string {
 }
 
 // Run : Runs the [lemon-cli] for the found metric's list and populates the expression [valueList] with the values fetched from the CLI.
-func (li LemonImpl) Run(contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error {
+func (li LemonImpl) Run(ctx context.Context, contextLogger *logger.Entry, metrics []string, valueList *map[string]interface{}) error {
 	if len(li.CommandPath) == 0 {
 		li.CommandPath = "/usr/sbin/lemon-cli"
 	}
@@ -55,7 +56,7 @@ func (li LemonImpl) Run(contextLogger *logger.Entry, metrics []string, valueList
 	contextLogger.Debugf("Running the [lemon] cli path [%s] for the metrics [%s]", li.CommandPath, metric)
 	// Add the [lemon-cli] arguments
 
-	output, err, stderr := runner.Run(li.CommandPath, true, 0, "--script", "-m", metric)
+	output, err, stderr := runner.RunContext(ctx, li.CommandPath, true, "--script", "-m", metric)
 	if err != nil {
 		return fmt.Errorf("failed to run the [lemon] cli with the error [%s] and Stderr[%s]", err.Error(), stderr)
 	}
@@ -2,9 +2,33 @@
 
 package lbconfig
 
-import logger "github.com/sirupsen/logrus"
+import (
+	"context"
 
-// CLI : generic interface for all the functions that run a CLI command
+	logger "github.com/sirupsen/logrus"
+)
+
+// CLI : generic interface for all the functions that run a CLI command. The arguments are the argument text of the
+// parsed action line (everything after the keyword, without the timeout annotation), the alias names and the default
+// flag of the configuration mapping
 type CLI interface {
-	Run(contextLogger *logger.Entry, args ...interface{}) (int, error)
+	Run(ctx context.Context, contextLogger *logger.Entry, args ...interface{}) (int, error)
+}
+
+// ArgumentsValidator : Optional interface of the CLIs that can validate their arguments without running anything. It
+// returns the warnings about the arguments, or an error if they are invalid
+type ArgumentsValidator interface {
+	ValidateArguments(args string) ([]string, error)
+}
+
+// NegativeLoadChecker : Optional interface of the CLIs used in the load lines that can tell, without running anything,
+// if their arguments may give a negative load (which excludes the node from the alias)
+type NegativeLoadChecker interface {
+	CanBeNegative(args string) bool
+}
+
+// ArgumentsFormatter : Optional interface of the CLIs whose arguments have a canonical form, used to format the
+// configuration files. It returns an error if the arguments cannot be parsed, in which case they are kept as written
+type ArgumentsFormatter interface {
+	FormatArguments(args string) (string, error)
 }
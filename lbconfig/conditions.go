@@ -0,0 +1,82 @@
+package lbconfig
+
+import (
+	"os"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+)
+
+// cursor : Walks through the statements of a configuration file. The blocks are entered on demand, once their
+// condition is known, since it may depend on the variables defined before them
+type cursor struct {
+	stack [][]config.Statement
+}
+
+// newCursor : Factory-pattern function that creates and returns a new @see cursor struct instance pointer
+func newCursor(statements []config.Statement) *cursor {
+	return &cursor{stack: [][]config.Statement{statements}}
+}
+
+// next : Returns the next statement, or false once all the statements were returned
+func (c *cursor) next() (config.Statement, bool) {
+	for len(c.stack) > 0 {
+		top := c.stack[len(c.stack)-1]
+		if len(top) == 0 {
+			c.stack = c.stack[:len(c.stack)-1]
+			continue
+		}
+		c.stack[len(c.stack)-1] = top[1:]
+		return top[0], true
+	}
+	return nil, false
+}
+
+// enter : Makes the given statements the next ones to be returned
+func (c *cursor) enter(statements []config.Statement) {
+	c.stack = append(c.stack, statements)
+}
+
+// blockError : Returns the first syntax error of the statements of a block, whichever branch they belong to, or nil if
+// there is none
+func blockError(block *config.If) error {
+	var blockErr error
+	config.Walk(append(append([]config.Statement{}, block.Then...), block.Else...), func(statement config.Statement) {
+		if bad, ok := statement.(*config.BadStatement); ok && blockErr == nil {
+			blockErr = bad.Err
+		}
+	})
+	return blockErr
+}
+
+// holds : Evaluates the condition of an if block with the variables of the given scope
+func holds(block *config.If, scope *config.Scope) (bool, error) {
+	condition := block.Condition
+	// The regular expressions are compiled by the parser, without expansion
+	value := condition.Value
+	if condition.Regexp == nil {
+		expanded, expandErr := scope.Expand(condition.Value, condition.ValuePos, block.Text)
+		if expandErr != nil {
+			return false, expandErr
+		}
+		value = expanded
+	}
+	if condition.Operator == config.OpFileExists {
+		_, err := os.Stat(value)
+		return (err == nil) != condition.Negated, nil
+	}
+
+	subject, found := scope.Lookup(condition.Variable())
+	if !found {
+		return false, config.Errorf(condition.Position, block.Text, "the variable [%s] is not defined",
+			condition.Subject)
+	}
+	switch condition.Operator {
+	case config.OpMatch:
+		return condition.Regexp.MatchString(subject), nil
+	case config.OpNotMatch:
+		return !condition.Regexp.MatchString(subject), nil
+	case config.OpEqual:
+		return subject == value, nil
+	}
+	return subject != value, nil
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Position : Location of a token in a configuration file. Lines and columns start at 1
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	if len(p.File) == 0 {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Node : Element of the syntax tree of a configuration file
+type Node interface {
+	Pos() Position
+}
+
+// Statement : Node of a single statement of a configuration file
+type Statement interface {
+	Node
+	// Source returns the source line of the statement
+	Source() string
+}
+
+// File : Syntax tree of a configuration file. The comments and the blank lines are not statements, but they are kept
+// so that the file can be written back
+type File struct {
+	Path       string
+	Statements []Statement
+	Comments   []*Comment
+	// Budget is the time budget of the whole file, or nil if the file does not have any
+	Budget *Budget
+	// Aggregate is the aggregation of the loads of the whole file, or nil if the loads are added up
+	Aggregate *Aggregate
+	// Includes are the paths of all the files included by the file, directly or not. Only set by @see Load
+	Includes []string
+}
+
+// Action : Statement of a check, a soft check or a load, e.g. [check collectd [load] < 10 timeout=5s]
+type Action struct {
+	Position Position
+	// Kind is either [check], [penalize] or [load]
+	Kind string
+	// Keyword is the upper-cased name of the CLI, e.g. [COLLECTD]
+	Keyword    string
+	KeywordPos Position
+	// Args is the argument text of the CLI as written in the file, without the timeout annotation
+	Args    string
+	ArgsPos Position
+	// Timeout is the one of the timeout annotation, or zero if the line does not have any
+	Timeout time.Duration
+	// Weight is the one of the weight annotation of a load line, or zero if the line does not have any
+	Weight    float64
+	WeightPos Position
+	// Penalty is the load added when the soft check of a [penalize] line fails (@see DefaultPenalty if the line does
+	// not have a penalty annotation)
+	Penalty int
+	Text    string
+	// Name is the name given to the check or load by the structured format, if any
+	Name string
+}
+
+// Pos : Returns the position of the statement
+func (a *Action) Pos() Position { return a.Position }
+
+// Source : Returns the source line of the statement
+func (a *Action) Source() string { return a.Text }
+
+// IsLoad : Tells if the action adds to the load of the node
+func (a *Action) IsLoad() bool { return a.Kind == KindLoad }
+
+// IsPenalty : Tells if the action is a soft check, which adds a penalty to the load of the node instead of excluding it
+func (a *Action) IsPenalty() bool { return a.Kind == KindPenalize }
+
+// annotation : Returns the value of the given annotation (e.g. [timeout=]) as written in the line, or an empty string
+// if the line does not have it
+func (a *Action) annotation(prefix string) string {
+	fields := strings.Fields(a.Text)
+	for i := len(fields) - 1; i > 0 && i >= len(fields)-len(annotations); i-- {
+		if strings.HasPrefix(strings.ToLower(fields[i]), prefix) {
+			return fields[i][len(prefix):]
+		}
+	}
+	return ""
+}
+
+// Budget : Statement of the time budget of the whole file, e.g. [timeout 1m]
+type Budget struct {
+	Position Position
+	Duration time.Duration
+	Text     string
+}
+
+// Pos : Returns the position of the statement
+func (b *Budget) Pos() Position { return b.Position }
+
+// Source : Returns the source line of the statement
+func (b *Budget) Source() string { return b.Text }
+
+// Aggregate : Statement of the aggregation of the loads of the whole file, e.g. [aggregate max]
+type Aggregate struct {
+	Position Position
+	// Method is one of the @see AggregateMethods, in lower case
+	Method    string
+	MethodPos Position
+	Text      string
+}
+
+// Pos : Returns the position of the statement
+func (a *Aggregate) Pos() Position { return a.Position }
+
+// Source : Returns the source line of the statement
+func (a *Aggregate) Source() string { return a.Text }
+
+// Aggregation methods of the loads
+const (
+	AggregateSum      = "sum"
+	AggregateMax      = "max"
+	AggregateMin      = "min"
+	AggregateAvg      = "avg"
+	AggregateWeighted = "weighted"
+)
+
+// AggregateMethods : The aggregation methods of the loads, the default one first
+var AggregateMethods = []string{AggregateSum, AggregateMax, AggregateMin, AggregateAvg, AggregateWeighted}
+
+// Include : Statement that includes other configuration files, e.g. [include common/*.conf]. Relative paths are
+// resolved from the directory of the including file
+type Include struct {
+	Position   Position
+	Pattern    string
+	PatternPos Position
+	Text       string
+}
+
+// Pos : Returns the position of the statement
+func (i *Include) Pos() Position { return i.Position }
+
+// Source : Returns the source line of the statement
+func (i *Include) Source() string { return i.Text }
+
+// Set : Statement that defines a variable, e.g. [set THRESHOLD = 10]. The value can use the variables defined before
+type Set struct {
+	Position Position
+	Name     string
+	Value    string
+	ValuePos Position
+	Text     string
+}
+
+// Pos : Returns the position of the statement
+func (s *Set) Pos() Position { return s.Position }
+
+// Source : Returns the source line of the statement
+func (s *Set) Source() string { return s.Text }
+
+// If : Block of statements that only apply when its condition holds, e.g. [if alias =~ /^web/ {]. The [else if]
+// blocks are kept as a single nested If in the Else statements
+type If struct {
+	Position  Position
+	Condition *Condition
+	Then      []Statement
+	Else      []Statement
+	Text      string
+	// Chained marks the [else if] blocks, whose position is the one of their [} else if] line
+	Chained bool
+	// ElsePos is the position of the [} else] line, if any, and EndPos the one of the closing [}]
+	ElsePos Position
+	EndPos  Position
+}
+
+// Pos : Returns the position of the statement
+func (i *If) Pos() Position { return i.Position }
+
+// Source : Returns the source line of the statement
+func (i *If) Source() string { return i.Text }
+
+// Condition : Condition of an @see If block. It either compares a variable ([alias], [hostname] or any other one) with
+// a regular expression or a value, or checks if a file exists
+type Condition struct {
+	Position Position
+	// Subject is the compared variable, as written. Empty for [file_exists]
+	Subject  string
+	Operator string
+	// Value is the path of [file_exists], the compared value or the source of the regular expression
+	Value    string
+	ValuePos Position
+	Regexp   *regexp.Regexp
+	// Negated is only used by [!file_exists]
+	Negated bool
+}
+
+// Variable : Returns the name of the variable compared by the condition. The [alias] and [hostname] subjects stand for
+// the facts of the same name
+func (c *Condition) Variable() string {
+	switch strings.ToLower(c.Subject) {
+	case "alias":
+		return "ALIAS"
+	case "hostname":
+		return "HOSTNAME"
+	}
+	return c.Subject
+}
+
+// Condition operators
+const (
+	OpMatch      = "=~"
+	OpNotMatch   = "!~"
+	OpEqual      = "=="
+	OpNotEqual   = "!="
+	OpFileExists = "file_exists"
+)
+
+// BadStatement : Placeholder of a line that could not be parsed. It keeps its place in the statements, so that the
+// evaluation reports the error when it reaches the line
+type BadStatement struct {
+	Position Position
+	Text     string
+	Err      *Error
+}
+
+// Pos : Returns the position of the statement
+func (b *BadStatement) Pos() Position { return b.Position }
+
+// Source : Returns the source line of the statement
+func (b *BadStatement) Source() string { return b.Text }
+
+// Comment : Comment line of a configuration file, e.g. [# The checks of the web servers]
+type Comment struct {
+	Position Position
+	Text     string
+}
+
+// Pos : Returns the position of the comment
+func (c *Comment) Pos() Position { return c.Position }
+
+// Statement kinds
+const (
+	KindCheck     = "check"
+	KindPenalize  = "penalize"
+	KindLoad      = "load"
+	KindTimeout   = "timeout"
+	KindAggregate = "aggregate"
+	KindInclude   = "include"
+	KindSet       = "set"
+	KindIf        = "if"
+)
+
+// Walk : Calls the given function for every statement, including the ones of the blocks, in the order of the file
+func Walk(statements []Statement, f func(Statement)) {
+	for _, statement := range statements {
+		f(statement)
+		if block, ok := statement.(*If); ok {
+			Walk(block.Then, f)
+			Walk(block.Else, f)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error : Error found at a given position of a configuration file. The message shows the source line, with a caret
+// under the column of the error
+type Error struct {
+	Pos    Position
+	Msg    string
+	Source string
+}
+
+// Errorf : Creates a new @see Error at the given position of the given source line
+func Errorf(pos Position, source string, format string, a ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, a...), Source: source}
+}
+
+func (e *Error) Error() string {
+	if len(e.Source) == 0 {
+		return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s\n\t%s\n\t%s^", e.Pos, e.Msg, e.Source, caretIndent(e.Source, e.Pos.Column))
+}
+
+// caretIndent : Returns the indentation that puts a caret under the given column of the line. Tabs are kept, so that
+// the caret is aligned whatever the width of the tabs is
+func caretIndent(line string, column int) string {
+	var indent strings.Builder
+	for i, r := range []rune(line) {
+		if i >= column-1 {
+			break
+		}
+		if r == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+	return indent.String()
+}
+
+// ErrorList : List of the errors of a configuration file, in the order of the file
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Err : Returns the list as an error, or nil if the list is empty
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
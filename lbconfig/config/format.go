@@ -0,0 +1,156 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// formatter : Writes the canonical form of a configuration file. The comments are written before the first statement
+// that follows them, and last is the source line of the last written line, to keep the blank lines between paragraphs
+type formatter struct {
+	out       strings.Builder
+	comments  []*Comment
+	last      int
+	arguments func(action *Action) string
+}
+
+// Format : Returns the canonical form of a parsed configuration file: lower-case keywords, single spaces between the
+// words, the penalty and the weight before the timeout, blocks indented by two spaces, and at most one blank line
+// between the statements. The comments are kept. The arguments of the actions are the ones returned by the given
+// function, which may canonicalise them. The file should not have any error, nor be loaded with @see Load
+func Format(file *File, arguments func(action *Action) string) []byte {
+	f := &formatter{comments: file.Comments, arguments: arguments}
+	f.statements(file.Statements, 0)
+	f.flush(int(^uint(0)>>1), 0)
+	return []byte(f.out.String())
+}
+
+// statements : Writes the given statements, at the given block depth
+func (f *formatter) statements(statements []Statement, depth int) {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *Action:
+			line := s.Kind + " " + strings.ToLower(s.Keyword)
+			if args := f.arguments(s); len(args) != 0 {
+				line += " " + args
+			}
+			if len(s.annotation(penaltyAnnotation)) != 0 && s.IsPenalty() {
+				line += " " + penaltyAnnotation + strconv.Itoa(s.Penalty)
+			}
+			if s.Weight > 0 {
+				line += " " + weightAnnotation + strconv.FormatFloat(s.Weight, 'g', -1, 64)
+			}
+			if s.Timeout > 0 {
+				line += " " + timeoutAnnotation + s.annotation(timeoutAnnotation)
+			}
+			f.write(s.Position.Line, depth, line)
+		case *Budget:
+			f.write(s.Position.Line, depth, KindTimeout+" "+strings.Fields(s.Text)[1])
+		case *Aggregate:
+			f.write(s.Position.Line, depth, KindAggregate+" "+s.Method)
+		case *Include:
+			f.write(s.Position.Line, depth, KindInclude+" "+s.Pattern)
+		case *Set:
+			f.write(s.Position.Line, depth, KindSet+" "+s.Name+" = "+s.Value)
+		case *If:
+			f.block(s, depth, KindIf+" ")
+		case *BadStatement:
+			f.write(s.Position.Line, depth, strings.TrimSpace(s.Text))
+		}
+	}
+}
+
+// block : Writes an if block, whose first line starts with the given prefix ([if] or [} else if])
+func (f *formatter) block(block *If, depth int, prefix string) {
+	f.write(block.Position.Line, depth, prefix+conditionText(block.Condition, false)+" {")
+	f.statements(block.Then, depth+1)
+	if block.ElsePos.Line != 0 {
+		// An empty [else] block is kept, for its comments
+		f.flush(block.ElsePos.Line, depth+1)
+		if chained, ok := firstStatement(block.Else).(*If); ok && chained.Chained {
+			// The chained block writes the closing brace
+			f.block(chained, depth, "} else if ")
+			return
+		}
+		f.write(block.ElsePos.Line, depth, "} else {")
+		f.statements(block.Else, depth+1)
+	}
+	f.flush(block.EndPos.Line, depth+1)
+	f.write(block.EndPos.Line, depth, "}")
+}
+
+// firstStatement : Returns the first of the given statements, if any
+func firstStatement(statements []Statement) Statement {
+	if len(statements) == 0 {
+		return nil
+	}
+	return statements[0]
+}
+
+// write : Writes a line found at the given source line, after the comments that precede it
+func (f *formatter) write(line, depth int, text string) {
+	f.flush(line, depth)
+	f.emit(line, depth, text)
+}
+
+// flush : Writes the comments found before the given source line
+func (f *formatter) flush(line, depth int) {
+	for len(f.comments) != 0 && f.comments[0].Position.Line < line {
+		f.emit(f.comments[0].Position.Line, depth, f.comments[0].Text)
+		f.comments = f.comments[1:]
+	}
+}
+
+// emit : Writes a line, separated by a blank line from the previous one if they were not consecutive in the source
+func (f *formatter) emit(line, depth int, text string) {
+	if f.last != 0 && line > f.last+1 {
+		f.out.WriteByte('\n')
+	}
+	f.out.WriteString(indent(depth) + text + "\n")
+	f.last = line
+}
+
+// CanonicalJSON : Returns the canonical JSON form of a decoded value, on a single line, with the keys of the objects
+// sorted and a space after the colons and the commas
+func CanonicalJSON(value interface{}) (string, error) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	inString, escaped := false, false
+	for _, c := range strings.TrimSpace(encoded.String()) {
+		out.WriteRune(c)
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ':' || c == ','):
+			out.WriteByte(' ')
+		}
+	}
+	return out.String(), nil
+}
+
+// DecodeJSON : Decodes a JSON text, keeping the numbers as written
+func DecodeJSON(text string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.Decode(&struct{}{}) != io.EOF {
+		return nil, fmt.Errorf("unexpected text after the JSON value")
+	}
+	return value, nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// loader : Expands the include statements of a configuration file. The stack holds the files being loaded, to detect
+// the cycles
+type loader struct {
+	stack    []string
+	included map[string]bool
+	errors   ErrorList
+}
+
+// Load : Parses the given configuration file and the files it includes. The include statements are replaced by the
+// statements of the included files, which keep their own positions. The statements of the given drop-in fragments (and
+// of the files they include) are appended in the given order. The main file may be missing if there are fragments.
+// As with @see Parse, the lines that cannot be parsed (and the includes that cannot be resolved) are kept as
+// @see BadStatement, and all their errors are returned. The time budget and the aggregation of the loads can only be
+// given in the main file
+func Load(path string, fragments ...string) (*File, error) {
+	file, err := ParseFile(path)
+	if file == nil {
+		if len(fragments) == 0 || !os.IsNotExist(err) {
+			return nil, err
+		}
+		file, err = &File{Path: path}, nil
+	}
+	l := &loader{included: make(map[string]bool)}
+	if list, ok := err.(ErrorList); ok {
+		l.errors = list
+	}
+	l.stack = append(l.stack, absolute(path))
+	file.Statements = l.expand(file, file.Statements)
+	for _, fragment := range fragments {
+		file.Statements = append(file.Statements, l.load(fragment, nil)...)
+	}
+	for included := range l.included {
+		file.Includes = append(file.Includes, included)
+	}
+	sort.Strings(file.Includes)
+	return file, l.errors.Err()
+}
+
+// expand : Returns the given statements of the file, with the include statements (including the ones of the blocks)
+// replaced by the included statements
+func (l *loader) expand(file *File, list []Statement) (statements []Statement) {
+	for _, statement := range list {
+		if block, ok := statement.(*If); ok {
+			block.Then, block.Else = l.expand(file, block.Then), l.expand(file, block.Else)
+		}
+		include, ok := statement.(*Include)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+		paths, err := l.resolve(file, include)
+		if err != nil {
+			l.errors = append(l.errors, err)
+			statements = append(statements, &BadStatement{Position: include.Position, Text: include.Text, Err: err})
+			continue
+		}
+		for _, path := range paths {
+			statements = append(statements, l.load(path, include)...)
+		}
+	}
+	return statements
+}
+
+// resolve : Returns the paths matched by an include statement, in lexical order. A glob may match no file at all, but
+// a plain path must exist
+func (l *loader) resolve(file *File, include *Include) ([]string, *Error) {
+	pattern := include.Pattern
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(file.Path), pattern)
+	}
+	if !strings.ContainsAny(include.Pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, Errorf(include.PatternPos, include.Text, "unable to include [%s]: %v", include.Pattern, err)
+		}
+		return []string{pattern}, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, Errorf(include.PatternPos, include.Text, "invalid include pattern [%s]: %v", include.Pattern, err)
+	}
+	return paths, nil
+}
+
+// load : Parses and expands an included file, or a drop-in fragment if there is no include statement. Returns a bad
+// statement if the file is already being loaded
+func (l *loader) load(path string, include *Include) []Statement {
+	abs := absolute(path)
+	for i, loading := range l.stack {
+		if loading == abs {
+			cycle := append(append([]string{}, l.stack[i:]...), abs)
+			return l.fail(include, path, "include cycle [%s]", strings.Join(cycle, " -> "))
+		}
+	}
+	if include != nil {
+		l.included[abs] = true
+	}
+
+	file, err := ParseFile(path)
+	if file == nil {
+		return l.fail(include, path, "unable to include [%s]: %v", path, err)
+	}
+	if list, ok := err.(ErrorList); ok {
+		l.errors = append(l.errors, list...)
+	}
+	if file.Budget != nil {
+		l.mainOnly(file, file.Budget, "the time budget")
+	}
+	if file.Aggregate != nil {
+		l.mainOnly(file, file.Aggregate, "the aggregation of the loads")
+	}
+
+	l.stack = append(l.stack, abs)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+	return l.expand(file, file.Statements)
+}
+
+// mainOnly : Replaces a statement that can only be given in the main configuration file (e.g. the time budget) by a
+// bad statement
+func (l *loader) mainOnly(file *File, directive Statement, what string) {
+	err := Errorf(directive.Pos(), directive.Source(), "%s can only be given in the main configuration file", what)
+	l.errors = append(l.errors, err)
+	for i, statement := range file.Statements {
+		if statement == directive {
+			file.Statements[i] = &BadStatement{Position: directive.Pos(), Text: directive.Source(), Err: err}
+		}
+	}
+}
+
+// fail : Records an error about a file that cannot be loaded. The error points at the include statement, or at the
+// start of the file for the drop-in fragments
+func (l *loader) fail(include *Include, path string, format string, a ...interface{}) []Statement {
+	var err *Error
+	statement := &BadStatement{Position: Position{File: path, Line: 1, Column: 1}}
+	if include != nil {
+		err = Errorf(include.PatternPos, include.Text, format, a...)
+		statement.Position, statement.Text = include.Position, include.Text
+	} else {
+		err = Errorf(statement.Position, "", format, a...)
+	}
+	statement.Err = err
+	l.errors = append(l.errors, err)
+	return []Statement{statement}
+}
+
+// absolute : Returns the cleaned absolute version of a path, to compare the paths of the files
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"unicode/utf8"
+)
+
+// TokenKind : Type of a lexical token of a configuration file
+type TokenKind int
+
+// Token kinds
+const (
+	// TokenEOF marks the end of the file
+	TokenEOF TokenKind = iota
+	// TokenNewline marks the end of a line
+	TokenNewline
+	// TokenComment is a whole comment line, starting with [#]
+	TokenComment
+	// TokenWord is a run of non-blank characters
+	TokenWord
+)
+
+// Token : Lexical token of a configuration file. Offset and End delimit the token in the source, so that the parser
+// can take the argument text as written, with its original spacing
+type Token struct {
+	Kind   TokenKind
+	Text   string
+	Pos    Position
+	Offset int
+	End    int
+}
+
+// lexer : Splits the source of a configuration file into tokens. The language is line-based, so the words are only
+// separated by blanks, and the comments take whole lines
+type lexer struct {
+	src    string
+	file   string
+	offset int
+	line   int
+	column int
+	// lineStart tells that no token was emitted yet in the current line
+	lineStart bool
+}
+
+// newLexer : Factory-pattern function that creates and returns a new @see lexer struct instance pointer
+func newLexer(file, src string) *lexer {
+	return &lexer{src: src, file: file, line: 1, column: 1, lineStart: true}
+}
+
+// next : Returns the next token of the source
+func (l *lexer) next() Token {
+	l.skipBlanks()
+	pos := Position{File: l.file, Line: l.line, Column: l.column}
+	start := l.offset
+	if l.offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: pos, Offset: start, End: start}
+	}
+
+	switch {
+	case l.src[l.offset] == '\n':
+		l.advance()
+		l.line, l.column, l.lineStart = l.line+1, 1, true
+		return Token{Kind: TokenNewline, Text: "\n", Pos: pos, Offset: start, End: start + 1}
+	case l.src[l.offset] == '#' && l.lineStart:
+		for l.offset < len(l.src) && l.src[l.offset] != '\n' {
+			l.advance()
+		}
+		return Token{Kind: TokenComment, Text: trimRight(l.src[start:l.offset]), Pos: pos, Offset: start, End: l.offset}
+	}
+
+	l.lineStart = false
+	for l.offset < len(l.src) && !isBlank(l.src[l.offset]) && l.src[l.offset] != '\n' {
+		l.advance()
+	}
+	return Token{Kind: TokenWord, Text: l.src[start:l.offset], Pos: pos, Offset: start, End: l.offset}
+}
+
+// skipBlanks : Skips the spaces, the tabs and the carriage returns
+func (l *lexer) skipBlanks() {
+	for l.offset < len(l.src) && isBlank(l.src[l.offset]) {
+		l.advance()
+	}
+}
+
+// advance : Moves to the next character. The columns count characters, not bytes
+func (l *lexer) advance() {
+	_, size := utf8.DecodeRuneInString(l.src[l.offset:])
+	l.offset += size
+	l.column++
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func trimRight(s string) string {
+	for len(s) > 0 && isBlank(s[len(s)-1]) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
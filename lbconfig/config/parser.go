@@ -0,0 +1,433 @@
+package config
+
+import (
+	"io/ioutil"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyword : Syntax of the CLI names, e.g. [COLLECTD_ALARMS]
+var keyword = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Prefixes of the annotations at the end of an action line, e.g. [timeout=5s], [weight=0.7] or [penalty=200]
+const (
+	timeoutAnnotation = "timeout="
+	weightAnnotation  = "weight="
+	penaltyAnnotation = "penalty="
+)
+
+// annotations : The annotations of the action lines. The weight is only an annotation of the load lines, and the
+// penalty of the [penalize] lines
+var annotations = []string{timeoutAnnotation, weightAnnotation, penaltyAnnotation}
+
+// DefaultPenalty : Load added by a failing [penalize] line without a penalty annotation
+const DefaultPenalty = 100
+
+// parser : Builds the syntax tree of a configuration file from the tokens of the @see lexer
+type parser struct {
+	lexer  *lexer
+	file   *File
+	errors ErrorList
+	// blocks are the if blocks whose closing brace was not found yet, the innermost last
+	blocks []*block
+}
+
+// block : If block being parsed
+type block struct {
+	node   *If
+	inElse bool
+	// chained marks the [else if] blocks, which are closed by the brace of the block they continue
+	chained bool
+}
+
+// ParseFile : Reads and parses the given configuration file, either in the line format (see @see Parse) or in a
+// structured format (see @see ParseStructured)
+func ParseFile(path string) (*File, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if IsStructured(path) {
+		return ParseStructured(path, src)
+	}
+	return Parse(path, src)
+}
+
+// Parse : Parses the source of a configuration file. The parsing does not stop at the first error: the lines that
+// cannot be parsed are kept as @see BadStatement, and all their errors are returned as an @see ErrorList
+func Parse(path string, src []byte) (*File, error) {
+	p := &parser{lexer: newLexer(path, string(src)), file: &File{Path: path}}
+	for p.parseLine() {
+	}
+	for _, open := range p.blocks {
+		if !open.chained {
+			err := p.errorf(open.node.Position, "missing the closing [}] of the block")
+			p.errors = append(p.errors, err)
+			p.file.Statements = append(p.file.Statements, &BadStatement{Position: open.node.Position,
+				Text: open.node.Text, Err: err})
+		}
+	}
+	return p.file, p.errors.Err()
+}
+
+// parseLine : Parses the next line of the source. Returns false once the end of the source is reached
+func (p *parser) parseLine() bool {
+	var tokens []Token
+	for {
+		token := p.lexer.next()
+		switch token.Kind {
+		case TokenComment:
+			p.file.Comments = append(p.file.Comments, &Comment{Position: token.Pos, Text: token.Text})
+			continue
+		case TokenWord:
+			tokens = append(tokens, token)
+			continue
+		}
+		if len(tokens) > 0 {
+			p.parseStatement(tokens)
+		}
+		return token.Kind != TokenEOF
+	}
+}
+
+// parseStatement : Parses the tokens of a single line
+func (p *parser) parseStatement(tokens []Token) {
+	var statement Statement
+	var err *Error
+	switch strings.ToLower(tokens[0].Text) {
+	case KindCheck, KindPenalize, KindLoad:
+		statement, err = p.parseAction(tokens)
+	case KindTimeout:
+		statement, err = p.parseBudget(tokens)
+	case KindAggregate:
+		statement, err = p.parseAggregate(tokens)
+	case KindInclude:
+		statement, err = p.parseInclude(tokens)
+	case KindSet:
+		statement, err = p.parseSet(tokens)
+	case KindIf:
+		statement, err = p.parseIf(tokens)
+	case "}":
+		if err = p.parseClose(tokens); err == nil {
+			return
+		}
+	default:
+		err = p.errorf(tokens[0].Pos, "unknown statement [%s]. Expected [check], [penalize], [load], [timeout], "+
+			"[aggregate], [include], [set] or [if]", tokens[0].Text)
+	}
+	if err != nil {
+		p.errors = append(p.errors, err)
+		statement = &BadStatement{Position: tokens[0].Pos, Text: p.sourceLine(tokens[0]), Err: err}
+	}
+	p.append(statement)
+	if node, ok := statement.(*If); ok {
+		p.blocks = append(p.blocks, &block{node: node})
+	} else if strings.ToLower(tokens[0].Text) == KindIf && tokens[len(tokens)-1].Text == "{" {
+		// Keep the braces balanced after an invalid condition. The statements of the block are dropped
+		p.blocks = append(p.blocks, &block{node: &If{Position: tokens[0].Pos, Text: p.sourceLine(tokens[0])}})
+	}
+}
+
+// append : Adds a statement to the innermost open block, or to the file
+func (p *parser) append(statement Statement) {
+	if len(p.blocks) == 0 {
+		p.file.Statements = append(p.file.Statements, statement)
+		return
+	}
+	current := p.blocks[len(p.blocks)-1]
+	if current.inElse {
+		current.node.Else = append(current.node.Else, statement)
+	} else {
+		current.node.Then = append(current.node.Then, statement)
+	}
+}
+
+// parseIf : Parses an [if <condition> {] line
+func (p *parser) parseIf(tokens []Token) (*If, *Error) {
+	last := tokens[len(tokens)-1]
+	if last.Text != "{" {
+		return nil, p.errorf(p.endOf(last), "missing the [{] at the end of the condition")
+	}
+	if len(tokens) < 3 {
+		return nil, p.errorf(last.Pos, "missing the condition of the block (e.g. [if alias =~ /^www/ {])")
+	}
+	condition, err := p.parseCondition(tokens[1 : len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	return &If{Position: tokens[0].Pos, Condition: condition, Text: p.sourceLine(tokens[0])}, nil
+}
+
+// parseCondition : Parses a [<variable> =~|!~ /<regexp>/], [<variable> ==|!= <value>] or [[!]file_exists <path>]
+// condition
+func (p *parser) parseCondition(tokens []Token) (*Condition, *Error) {
+	first, last := tokens[0], tokens[len(tokens)-1]
+	condition := &Condition{Position: first.Pos}
+	if name := strings.ToLower(first.Text); name == OpFileExists || name == "!"+OpFileExists {
+		if len(tokens) < 2 {
+			return nil, p.errorf(p.endOf(first), "missing the path of [%s]", first.Text)
+		}
+		condition.Operator, condition.Negated = OpFileExists, strings.HasPrefix(name, "!")
+		condition.Value, condition.ValuePos = p.lexer.src[tokens[1].Offset:last.End], tokens[1].Pos
+		return condition, nil
+	}
+
+	if !variableName.MatchString(first.Text) {
+		return nil, p.errorf(first.Pos, "invalid condition [%s]. Expected a variable (e.g. [alias] or [hostname]) "+
+			"or [file_exists]", first.Text)
+	}
+	if len(tokens) < 3 {
+		return nil, p.errorf(p.endOf(last), "incomplete condition. Expected [%s =~ /<regexp>/] or "+
+			"[%s == <value>]", first.Text, first.Text)
+	}
+	condition.Subject, condition.Operator = first.Text, tokens[1].Text
+	condition.Value, condition.ValuePos = p.lexer.src[tokens[2].Offset:last.End], tokens[2].Pos
+	switch condition.Operator {
+	case OpEqual, OpNotEqual:
+		return condition, nil
+	case OpMatch, OpNotMatch:
+	default:
+		return nil, p.errorf(tokens[1].Pos, "unknown operator [%s]. Expected one of [%s, %s, %s, %s]",
+			condition.Operator, OpMatch, OpNotMatch, OpEqual, OpNotEqual)
+	}
+	value := condition.Value
+	if len(value) < 2 || value[0] != '/' || value[len(value)-1] != '/' {
+		return nil, p.errorf(condition.ValuePos, "the regular expression must be written between slashes "+
+			"(e.g. [/^www/])")
+	}
+	condition.Value = value[1 : len(value)-1]
+	var err error
+	if condition.Regexp, err = regexp.Compile(condition.Value); err != nil {
+		return nil, p.errorf(condition.ValuePos, "invalid regular expression: %v", err)
+	}
+	return condition, nil
+}
+
+// parseClose : Parses a [}], [} else {] or [} else if <condition> {] line
+func (p *parser) parseClose(tokens []Token) *Error {
+	if len(p.blocks) == 0 {
+		return p.errorf(tokens[0].Pos, "unexpected [}] outside of a block")
+	}
+	current := p.blocks[len(p.blocks)-1]
+	if len(tokens) == 1 {
+		// Closing an [else if] block also closes the blocks it continues
+		for closed := current; closed.chained; closed = p.blocks[len(p.blocks)-1] {
+			closed.node.EndPos = tokens[0].Pos
+			p.blocks = p.blocks[:len(p.blocks)-1]
+		}
+		p.blocks[len(p.blocks)-1].node.EndPos = tokens[0].Pos
+		p.blocks = p.blocks[:len(p.blocks)-1]
+		return nil
+	}
+	if strings.ToLower(tokens[1].Text) != "else" {
+		return p.errorf(tokens[1].Pos, "unexpected [%s] after [}]", tokens[1].Text)
+	}
+	if current.inElse {
+		return p.errorf(tokens[1].Pos, "the block already has an [else]")
+	}
+	if len(tokens) == 3 && tokens[2].Text == "{" {
+		current.inElse, current.node.ElsePos = true, tokens[0].Pos
+		return nil
+	}
+	if len(tokens) < 3 || strings.ToLower(tokens[2].Text) != KindIf {
+		return p.errorf(p.endOf(tokens[1]), "expected [} else {] or [} else if <condition> {]")
+	}
+	node, err := p.parseIf(tokens[2:])
+	if err != nil {
+		return err
+	}
+	node.Position, node.Text, node.Chained = tokens[0].Pos, p.sourceLine(tokens[0]), true
+	current.inElse, current.node.ElsePos = true, tokens[0].Pos
+	current.node.Else = append(current.node.Else, node)
+	p.blocks = append(p.blocks, &block{node: node, chained: true})
+	return nil
+}
+
+// parseAction : Parses a [check|penalize|load <keyword> [arguments] [annotations]] line, e.g. [timeout=<duration>]
+func (p *parser) parseAction(tokens []Token) (Statement, *Error) {
+	first := tokens[0]
+	action := &Action{Position: first.Pos, Kind: strings.ToLower(first.Text), Text: p.sourceLine(first)}
+	if len(tokens) < 2 {
+		return nil, p.errorf(p.endOf(first), "missing the keyword of the %s (e.g. [%s constant 5])",
+			action.Kind, action.Kind)
+	}
+	if !keyword.MatchString(tokens[1].Text) {
+		return nil, p.errorf(tokens[1].Pos, "invalid keyword [%s]", tokens[1].Text)
+	}
+	action.Keyword, action.KeywordPos = strings.ToUpper(tokens[1].Text), tokens[1].Pos
+
+	// The annotations can be given in any order, each of them once
+	args := tokens[2:]
+	for len(args) != 0 {
+		last := args[len(args)-1]
+		annotation := strings.ToLower(last.Text)
+		if strings.HasPrefix(annotation, timeoutAnnotation) && action.Timeout == 0 {
+			value := last.Text[len(timeoutAnnotation):]
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration <= 0 {
+				return nil, p.errorf(last.Pos, "invalid timeout [%s]. Please use a positive duration "+
+					"(e.g. [timeout=5s])", value)
+			}
+			action.Timeout = duration
+		} else if strings.HasPrefix(annotation, weightAnnotation) && action.IsLoad() && action.Weight == 0 {
+			value := last.Text[len(weightAnnotation):]
+			weight, err := strconv.ParseFloat(value, 64)
+			if err != nil || weight <= 0 || math.IsInf(weight, 1) {
+				return nil, p.errorf(last.Pos, "invalid weight [%s]. Please use a positive number "+
+					"(e.g. [weight=0.7])", value)
+			}
+			action.Weight, action.WeightPos = weight, last.Pos
+		} else if strings.HasPrefix(annotation, penaltyAnnotation) && action.IsPenalty() && action.Penalty == 0 {
+			value := last.Text[len(penaltyAnnotation):]
+			penalty, err := strconv.Atoi(value)
+			if err != nil || penalty <= 0 {
+				return nil, p.errorf(last.Pos, "invalid penalty [%s]. Please use a positive integer "+
+					"(e.g. [penalty=200])", value)
+			}
+			action.Penalty = penalty
+		} else {
+			break
+		}
+		args = args[:len(args)-1]
+	}
+	if action.IsPenalty() && action.Penalty == 0 {
+		action.Penalty = DefaultPenalty
+	}
+	if len(args) == 0 {
+		action.ArgsPos = p.endOf(tokens[1])
+	} else {
+		action.Args, action.ArgsPos = p.lexer.src[args[0].Offset:args[len(args)-1].End], args[0].Pos
+	}
+	return action, nil
+}
+
+// parseBudget : Parses a [timeout <duration>] line. A file can only have one
+func (p *parser) parseBudget(tokens []Token) (Statement, *Error) {
+	first := tokens[0]
+	if len(p.blocks) != 0 {
+		return nil, p.errorf(first.Pos, "the time budget cannot be given inside a block")
+	}
+	if len(tokens) != 2 {
+		column := p.endOf(first)
+		if len(tokens) > 2 {
+			column = tokens[2].Pos
+		}
+		return nil, p.errorf(column, "the time budget expects a single duration (e.g. [timeout 1m])")
+	}
+	duration, err := time.ParseDuration(tokens[1].Text)
+	if err != nil || duration <= 0 {
+		return nil, p.errorf(tokens[1].Pos, "invalid time budget [%s]. Please use a positive duration "+
+			"(e.g. [timeout 1m])", tokens[1].Text)
+	}
+	if p.file.Budget != nil {
+		return nil, p.errorf(first.Pos, "the time budget is given more than once (first at %s)",
+			p.file.Budget.Position)
+	}
+	p.file.Budget = &Budget{Position: first.Pos, Duration: duration, Text: p.sourceLine(first)}
+	return p.file.Budget, nil
+}
+
+// parseAggregate : Parses an [aggregate sum|max|min|avg|weighted] line. A file can only have one
+func (p *parser) parseAggregate(tokens []Token) (Statement, *Error) {
+	first := tokens[0]
+	if len(p.blocks) != 0 {
+		return nil, p.errorf(first.Pos, "the aggregation of the loads cannot be given inside a block")
+	}
+	if len(tokens) != 2 {
+		column := p.endOf(first)
+		if len(tokens) > 2 {
+			column = tokens[2].Pos
+		}
+		return nil, p.errorf(column, "the aggregation expects a single method, one of [%s]",
+			strings.Join(AggregateMethods, ", "))
+	}
+	method := strings.ToLower(tokens[1].Text)
+	known := false
+	for _, candidate := range AggregateMethods {
+		known = known || candidate == method
+	}
+	if !known {
+		return nil, p.errorf(tokens[1].Pos, "unknown aggregation [%s]. Expected one of [%s]", tokens[1].Text,
+			strings.Join(AggregateMethods, ", "))
+	}
+	if p.file.Aggregate != nil {
+		return nil, p.errorf(first.Pos, "the aggregation of the loads is given more than once (first at %s)",
+			p.file.Aggregate.Position)
+	}
+	p.file.Aggregate = &Aggregate{Position: first.Pos, Method: method, MethodPos: tokens[1].Pos,
+		Text: p.sourceLine(first)}
+	return p.file.Aggregate, nil
+}
+
+// parseInclude : Parses an [include <path-or-glob>] line
+func (p *parser) parseInclude(tokens []Token) (Statement, *Error) {
+	first := tokens[0]
+	if len(tokens) < 2 {
+		return nil, p.errorf(p.endOf(first), "missing the path of the included file (e.g. [include common.conf])")
+	}
+	return &Include{
+		Position:   first.Pos,
+		Pattern:    p.lexer.src[tokens[1].Offset:tokens[len(tokens)-1].End],
+		PatternPos: tokens[1].Pos,
+		Text:       p.sourceLine(first),
+	}, nil
+}
+
+// parseSet : Parses a [set NAME = value] line. The value is the rest of the line, and may be empty
+func (p *parser) parseSet(tokens []Token) (Statement, *Error) {
+	first := tokens[0]
+	if len(tokens) < 2 {
+		return nil, p.errorf(p.endOf(first), "missing the name of the variable (e.g. [set THRESHOLD = 10])")
+	}
+	raw := p.lexer.src[tokens[1].Offset:tokens[len(tokens)-1].End]
+	equals := strings.IndexByte(raw, '=')
+	if equals < 0 {
+		return nil, p.errorf(p.endOf(tokens[len(tokens)-1]), "missing the [=] of the variable definition "+
+			"(e.g. [set THRESHOLD = 10])")
+	}
+	name := trimRight(raw[:equals])
+	if !variableName.MatchString(name) {
+		return nil, p.errorf(tokens[1].Pos, "invalid variable name [%s]", name)
+	}
+	start := equals + 1
+	for start < len(raw) && isBlank(raw[start]) {
+		start++
+	}
+	valuePos := tokens[1].Pos
+	valuePos.Column += len([]rune(raw[:start]))
+	return &Set{Position: first.Pos, Name: name, Value: raw[start:], ValuePos: valuePos, Text: p.sourceLine(first)}, nil
+}
+
+// errorf : Creates an @see Error at the given position, showing its source line
+func (p *parser) errorf(pos Position, format string, a ...interface{}) *Error {
+	return Errorf(pos, p.lineAt(pos), format, a...)
+}
+
+// endOf : Returns the position right after the given token
+func (p *parser) endOf(token Token) Position {
+	pos := token.Pos
+	pos.Column += len([]rune(token.Text)) + 1
+	return pos
+}
+
+// sourceLine : Returns the source line of the given token, without the trailing blanks
+func (p *parser) sourceLine(token Token) string {
+	start := strings.LastIndexByte(p.lexer.src[:token.Offset], '\n') + 1
+	end := strings.IndexByte(p.lexer.src[token.Offset:], '\n')
+	if end < 0 {
+		return trimRight(p.lexer.src[start:])
+	}
+	return trimRight(p.lexer.src[start : token.Offset+end])
+}
+
+// lineAt : Returns the source line at the given position
+func (p *parser) lineAt(pos Position) string {
+	lines := strings.SplitN(p.lexer.src, "\n", pos.Line+1)
+	if pos.Line-1 >= len(lines) {
+		return ""
+	}
+	return trimRight(lines[pos.Line-1])
+}
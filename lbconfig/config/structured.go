@@ -0,0 +1,567 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Formats of the configuration files
+const (
+	FormatConf = "conf"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Sections of a @see Document
+const (
+	sectionTimeout   = "timeout"
+	sectionAggregate = "aggregate"
+	sectionInclude   = "include"
+	sectionVariables = "variables"
+	sectionChecks    = "checks"
+	sectionPenalties = "penalties"
+	sectionLoads     = "loads"
+)
+
+// sectionKey : Syntax of the keys of a structured document, either in YAML or in JSON
+var sectionKey = regexp.MustCompile(`^\s*"?([A-Za-z_]+)"?\s*:`)
+
+// Document : Structured (YAML or JSON) form of a configuration file. It is loaded into the same syntax tree as the line
+// format, in the order of its sections: the time budget, the aggregation of the loads, the includes, the variables, the
+// checks, the soft checks (penalties) and the loads. The [when] conditions of an element use the syntax of the if
+// blocks, and all of them must hold
+type Document struct {
+	Timeout   string     `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Aggregate string     `yaml:"aggregate,omitempty" json:"aggregate,omitempty"`
+	Include   []string   `yaml:"include,omitempty" json:"include,omitempty"`
+	Variables []Variable `yaml:"variables,omitempty" json:"variables,omitempty"`
+	Checks    []Entry    `yaml:"checks,omitempty" json:"checks,omitempty"`
+	Penalties []Entry    `yaml:"penalties,omitempty" json:"penalties,omitempty"`
+	Loads     []Entry    `yaml:"loads,omitempty" json:"loads,omitempty"`
+}
+
+// Variable : Variable of a @see Document, the equivalent of a [set] line
+type Variable struct {
+	Name  string   `yaml:"name" json:"name"`
+	Value string   `yaml:"value" json:"value"`
+	When  []string `yaml:"when,omitempty" json:"when,omitempty"`
+}
+
+// Entry : Check, soft check or load of a @see Document. The arguments of the CLI are either given as text (args), or as
+// an object or a list (params) that is passed to the CLI as JSON, e.g. the port of the daemon check or the collectd
+// alarms
+type Entry struct {
+	Name    string      `yaml:"name,omitempty" json:"name,omitempty"`
+	Type    string      `yaml:"type" json:"type"`
+	Args    string      `yaml:"args,omitempty" json:"args,omitempty"`
+	Params  interface{} `yaml:"params,omitempty" json:"params,omitempty"`
+	Weight  string      `yaml:"weight,omitempty" json:"weight,omitempty"`
+	Penalty string      `yaml:"penalty,omitempty" json:"penalty,omitempty"`
+	Timeout string      `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	When    []string    `yaml:"when,omitempty" json:"when,omitempty"`
+}
+
+// renderedLine : Line of the line format rendered from an element of a @see Document
+type renderedLine struct {
+	text    string
+	section string
+	index   int
+	// name is the name of the check or load of the line, if any
+	name string
+}
+
+// IsStructured : Tells if the given configuration file uses the structured format, from its extension
+func IsStructured(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// ParseStructured : Parses the source of a structured configuration file. The document is rendered in the line format
+// and parsed as such, so the statements show the equivalent lines as their source, while their positions point at the
+// lines of the elements of the document. As with @see Parse, the statements that cannot be parsed are kept as
+// @see BadStatement. The documents that cannot be decoded are returned as an error, without a file
+func ParseStructured(path string, src []byte) (*File, error) {
+	document, err := decodeDocument(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid structured configuration file [%s]: %v", path, err)
+	}
+	lines, err := document.render()
+	if err != nil {
+		return nil, fmt.Errorf("invalid structured configuration file [%s]: %v", path, err)
+	}
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.text
+	}
+	file, parseErr := Parse(path, []byte(strings.Join(texts, "\n")))
+
+	Walk(file.Statements, func(statement Statement) {
+		if action, ok := statement.(*Action); ok && action.Position.Line >= 1 && action.Position.Line <= len(lines) {
+			action.Name = lines[action.Position.Line-1].name
+		}
+	})
+	located := locate(string(src))
+	remap(file, parseErr, func(pos *Position) {
+		if pos.Line >= 1 && pos.Line <= len(lines) {
+			line := lines[pos.Line-1]
+			pos.Line = located.line(line.section, line.index)
+		}
+	})
+	return file, parseErr
+}
+
+// decodeDocument : Decodes a structured document. JSON being a subset of YAML, both are decoded the same way. The
+// unknown keys are rejected
+func decodeDocument(src []byte) (*Document, error) {
+	document := &Document{}
+	if err := yaml.UnmarshalStrict(src, document); err != nil {
+		return nil, err
+	}
+	for _, entries := range [][]Entry{document.Checks, document.Penalties, document.Loads} {
+		for i := range entries {
+			entries[i].Params = jsonCompatible(entries[i].Params)
+		}
+	}
+	return document, nil
+}
+
+// jsonCompatible : Converts the maps decoded from YAML, whose keys can be of any type, to maps that can be encoded in
+// JSON
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+	}
+	return value
+}
+
+// render : Renders the document in the line format. The consecutive elements with the same conditions share their
+// blocks
+func (d *Document) render() ([]renderedLine, error) {
+	var lines []renderedLine
+	// open holds the conditions of the blocks that are not closed yet
+	var open []string
+	closeBlocks := func(keep int, section string, index int) {
+		for len(open) > keep {
+			open = open[:len(open)-1]
+			lines = append(lines, renderedLine{text: indent(len(open)) + "}", section: section, index: index})
+		}
+	}
+	add := func(section string, index int, when []string, text, name string) error {
+		// The names and the conditions are rendered on their own lines as well
+		for _, part := range append([]string{text, name}, when...) {
+			if strings.ContainsAny(part, "\r\n") {
+				return fmt.Errorf("the element %d of [%s] cannot span several lines", index+1, section)
+			}
+		}
+		common := 0
+		for common < len(open) && common < len(when) && open[common] == when[common] {
+			common++
+		}
+		closeBlocks(common, section, index)
+		for _, condition := range when[common:] {
+			lines = append(lines, renderedLine{text: indent(len(open)) + "if " + condition + " {", section: section,
+				index: index})
+			open = append(open, condition)
+		}
+		if len(name) != 0 {
+			lines = append(lines, renderedLine{text: indent(len(open)) + "# " + name, section: section, index: index})
+		}
+		lines = append(lines, renderedLine{text: indent(len(open)) + text, section: section, index: index, name: name})
+		return nil
+	}
+
+	if len(d.Timeout) != 0 {
+		if err := add(sectionTimeout, 0, nil, "timeout "+d.Timeout, ""); err != nil {
+			return nil, err
+		}
+	}
+	if len(d.Aggregate) != 0 {
+		if err := add(sectionAggregate, 0, nil, "aggregate "+d.Aggregate, ""); err != nil {
+			return nil, err
+		}
+	}
+	for i, pattern := range d.Include {
+		if err := add(sectionInclude, i, nil, "include "+pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	for i, variable := range d.Variables {
+		if err := add(sectionVariables, i, variable.When, "set "+variable.Name+" = "+variable.Value, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, section := range []struct {
+		name    string
+		kind    string
+		entries []Entry
+	}{{sectionChecks, KindCheck, d.Checks}, {sectionPenalties, KindPenalize, d.Penalties},
+		{sectionLoads, KindLoad, d.Loads}} {
+		for i, entry := range section.entries {
+			text, err := entry.line(section.kind)
+			if err != nil {
+				return nil, fmt.Errorf("the element %d of [%s]: %v", i+1, section.name, err)
+			}
+			if err = add(section.name, i, entry.When, text, entry.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(lines) != 0 {
+		last := lines[len(lines)-1]
+		closeBlocks(0, last.section, last.index)
+	}
+	return lines, nil
+}
+
+// line : Returns the action line of the entry
+func (e Entry) line(kind string) (string, error) {
+	line := kind + " " + e.Type
+	args := e.Args
+	if e.Params != nil {
+		if len(args) != 0 {
+			return "", fmt.Errorf("the [%s] %s has both [args] and [params]", e.Type, kind)
+		}
+		params, err := CanonicalJSON(e.Params)
+		if err != nil {
+			return "", err
+		}
+		args = params
+	}
+	if len(args) != 0 {
+		line += " " + args
+	}
+	if len(e.Penalty) != 0 {
+		line += " " + penaltyAnnotation + e.Penalty
+	}
+	if len(e.Weight) != 0 {
+		line += " " + weightAnnotation + e.Weight
+	}
+	if len(e.Timeout) != 0 {
+		line += " " + timeoutAnnotation + e.Timeout
+	}
+	return line, nil
+}
+
+// Format : Returns the document in the line format. The names of the checks and of the loads are written as comments
+func (d *Document) Format() (string, error) {
+	lines, err := d.render()
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for _, line := range lines {
+		out.WriteString(line.text)
+		out.WriteByte('\n')
+	}
+	return out.String(), nil
+}
+
+// indent : Returns the indentation of the statements of the given block depth
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// remap : Applies the given change to all the positions of a parsed file and of its errors
+func remap(file *File, err error, change func(pos *Position)) {
+	changed := make(map[*Error]bool)
+	changeError := func(e *Error) {
+		if e != nil && !changed[e] {
+			changed[e] = true
+			change(&e.Pos)
+		}
+	}
+	Walk(file.Statements, func(statement Statement) {
+		switch s := statement.(type) {
+		case *Action:
+			change(&s.Position)
+			change(&s.KeywordPos)
+			change(&s.ArgsPos)
+			change(&s.WeightPos)
+		case *Budget:
+			change(&s.Position)
+		case *Aggregate:
+			change(&s.Position)
+			change(&s.MethodPos)
+		case *Include:
+			change(&s.Position)
+			change(&s.PatternPos)
+		case *Set:
+			change(&s.Position)
+			change(&s.ValuePos)
+		case *If:
+			change(&s.Position)
+			change(&s.ElsePos)
+			change(&s.EndPos)
+			if s.Condition != nil {
+				change(&s.Condition.Position)
+				change(&s.Condition.ValuePos)
+			}
+		case *BadStatement:
+			change(&s.Position)
+			changeError(s.Err)
+		}
+	})
+	for _, comment := range file.Comments {
+		change(&comment.Position)
+	}
+	if list, ok := err.(ErrorList); ok {
+		for _, e := range list {
+			changeError(e)
+		}
+	}
+}
+
+// located : Lines of the sections of a structured document, and of their elements
+type located map[string]*section
+
+// section : Line of a section of a structured document, and the lines of its elements
+type section struct {
+	line  int
+	items []int
+}
+
+// locate : Finds the lines of the sections of a structured document and of their elements, as far as they can be told
+// from the source: the elements of the block sequences, and of the JSON arrays written one element per line. The other
+// elements are given the line of their section
+func locate(src string) located {
+	sections := make(located)
+	var current *section
+	topIndent, itemIndent := -1, -1
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indentation := len(line) - len(strings.TrimLeft(line, " \t"))
+		match := sectionKey.FindStringSubmatch(line)
+		if match != nil && topIndent < 0 {
+			topIndent = indentation
+		}
+		if match != nil && indentation == topIndent {
+			current = &section{line: i + 1}
+			sections[match[1]] = current
+			itemIndent = -1
+			continue
+		}
+		if current == nil || (indentation <= topIndent && !strings.HasPrefix(trimmed, "-")) {
+			continue
+		}
+		if itemIndent < 0 {
+			itemIndent = indentation
+		}
+		if indentation == itemIndent && strings.ContainsAny(trimmed[:1], "-{\"") {
+			current.items = append(current.items, i+1)
+		}
+	}
+	return sections
+}
+
+// line : Returns the line of the given element of a section, or of the section if it is not known
+func (l located) line(name string, index int) int {
+	found, ok := l[name]
+	if !ok {
+		return 1
+	}
+	if index < len(found.items) {
+		return found.items[index]
+	}
+	return found.line
+}
+
+// NewDocument : Translates a configuration file in the line format into a structured document. The include statements
+// are kept as they are, so the file should not be loaded with @see Load. The comment right above a check or a load
+// becomes its name, and the blocks become the conditions of their elements (negated in the else branches). The files
+// whose statements cannot be expressed in the structured format (or that have errors) are rejected
+func NewDocument(file *File) (*Document, error) {
+	c := &converter{document: &Document{}, comments: make(map[int]string), used: make(map[string]bool)}
+	for _, comment := range file.Comments {
+		if comment.Position.File == file.Path {
+			c.comments[comment.Position.Line] = strings.TrimSpace(strings.TrimPrefix(comment.Text, "#"))
+		}
+	}
+	if err := c.statements(file.Statements, nil); err != nil {
+		return nil, err
+	}
+	return c.document, nil
+}
+
+// converter : Translates the statements of a configuration file into a @see Document. The comments are indexed by
+// their line, and used holds the variables referenced so far
+type converter struct {
+	document *Document
+	comments map[int]string
+	used     map[string]bool
+}
+
+// statements : Translates the given statements, which only apply if all the given conditions hold
+func (c *converter) statements(statements []Statement, when []string) error {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *Budget:
+			c.document.Timeout = strings.Fields(s.Text)[1]
+		case *Aggregate:
+			c.document.Aggregate = s.Method
+		case *Include:
+			if len(when) != 0 {
+				return Errorf(s.Position, s.Text, "the includes inside a block cannot be converted")
+			}
+			c.document.Include = append(c.document.Include, s.Pattern)
+		case *Set:
+			// The variables are defined before all the checks and loads of the document
+			if c.used[s.Name] {
+				return Errorf(s.Position, s.Text, "the variable [%s] is set after being used, which cannot be "+
+					"converted", s.Name)
+			}
+			c.use(s.Value)
+			c.document.Variables = append(c.document.Variables, Variable{Name: s.Name, Value: s.Value, When: when})
+		case *Action:
+			c.use(s.Args)
+			entry := c.entry(s, when)
+			if s.IsLoad() {
+				c.document.Loads = append(c.document.Loads, entry)
+			} else if s.IsPenalty() {
+				c.document.Penalties = append(c.document.Penalties, entry)
+			} else {
+				c.document.Checks = append(c.document.Checks, entry)
+			}
+		case *If:
+			if s.Condition == nil {
+				continue
+			}
+			if s.Condition.Subject != "" {
+				c.used[s.Condition.Variable()] = true
+			}
+			c.use(s.Condition.Value)
+			then := append(append([]string{}, when...), conditionText(s.Condition, false))
+			if err := c.statements(s.Then, then); err != nil {
+				return err
+			}
+			otherwise := append(append([]string{}, when...), conditionText(s.Condition, true))
+			if err := c.statements(s.Else, otherwise); err != nil {
+				return err
+			}
+		case *BadStatement:
+			return s.Err
+		}
+	}
+	return nil
+}
+
+// use : Records the variables referenced by the given text
+func (c *converter) use(text string) {
+	for _, name := range referencedNames(text) {
+		c.used[name] = true
+	}
+}
+
+// entry : Translates a check or load line. The JSON objects, and the lists of JSON objects, become parameters
+func (c *converter) entry(action *Action, when []string) Entry {
+	entry := Entry{Name: c.comments[action.Position.Line-1], Type: strings.ToLower(action.Keyword),
+		Args: action.Args, When: when}
+	if action.IsPenalty() {
+		entry.Penalty = action.annotation(penaltyAnnotation)
+	}
+	if action.Weight > 0 {
+		entry.Weight = action.annotation(weightAnnotation)
+	}
+	if action.Timeout > 0 {
+		entry.Timeout = action.annotation(timeoutAnnotation)
+	}
+	var params interface{}
+	if json.Unmarshal([]byte(action.Args), &params) == nil && isObjects(params) {
+		entry.Args, entry.Params = "", params
+	}
+	return entry
+}
+
+// isObjects : Tells if a decoded JSON value is an object, or a non-empty list of objects
+func isObjects(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return len(v) != 0
+	}
+	return false
+}
+
+// conditionText : Returns the condition as written in an if block, or its opposite
+func conditionText(condition *Condition, negate bool) string {
+	operator := condition.Operator
+	if condition.Operator == OpFileExists {
+		if condition.Negated != negate {
+			operator = "!" + operator
+		}
+		return operator + " " + condition.Value
+	}
+	if negate {
+		operator = map[string]string{OpMatch: OpNotMatch, OpNotMatch: OpMatch, OpEqual: OpNotEqual,
+			OpNotEqual: OpEqual}[operator]
+	}
+	value := condition.Value
+	if condition.Regexp != nil {
+		value = "/" + value + "/"
+	}
+	// The facts are written in lower case
+	subject := condition.Subject
+	if strings.EqualFold(subject, "alias") || strings.EqualFold(subject, "hostname") {
+		subject = strings.ToLower(subject)
+	}
+	return subject + " " + operator + " " + value
+}
+
+// Convert : Translates a configuration file to the given format ([conf], [yaml] or [json]), from either the line format
+// or a structured format. See @see NewDocument for the translation of the line format
+func Convert(path, format string) ([]byte, error) {
+	var document *Document
+	if IsStructured(path) {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if document, err = decodeDocument(src); err != nil {
+			return nil, fmt.Errorf("invalid structured configuration file [%s]: %v", path, err)
+		}
+	} else {
+		file, err := ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if document, err = NewDocument(file); err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case FormatConf:
+		text, err := document.Format()
+		return []byte(text), err
+	case FormatJSON:
+		out, err := json.MarshalIndent(document, "", "  ")
+		return append(out, '\n'), err
+	}
+	return yaml.Marshal(document)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// reference : Detects the references to the variables, e.g. [${THRESHOLD}], and the escaped dollars [$$]
+	reference = regexp.MustCompile(`\$\$|\$\{([^}]*)}`)
+	// variableName : Syntax of the names of the variables
+	variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Scope : Variables visible from a statement. The variables defined with [set] hide the facts (e.g. [HOSTNAME]), which
+// hide the environment variables
+type Scope struct {
+	values map[string]string
+	facts  map[string]string
+}
+
+// NewScope : Factory-pattern function that creates and returns a new @see Scope struct instance pointer
+func NewScope(facts map[string]string) *Scope {
+	return &Scope{values: make(map[string]string), facts: facts}
+}
+
+// Set : Defines a variable, replacing its previous value if any
+func (s *Scope) Set(name, value string) {
+	s.values[name] = value
+}
+
+// Lookup : Returns the value of a variable, and whether it is defined
+func (s *Scope) Lookup(name string) (string, bool) {
+	if value, found := s.values[name]; found {
+		return value, true
+	}
+	if value, found := s.facts[name]; found {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// Expand : Replaces the [${NAME}] references of a text found at the given position of the given source line. [$$] is
+// replaced by a single dollar. The error points at the first reference that cannot be expanded
+func (s *Scope) Expand(text string, pos Position, source string) (string, *Error) {
+	var expanded strings.Builder
+	last := 0
+	for _, match := range reference.FindAllStringSubmatchIndex(text, -1) {
+		expanded.WriteString(text[last:match[0]])
+		last = match[1]
+		if match[2] < 0 {
+			expanded.WriteByte('$')
+			continue
+		}
+		name := text[match[2]:match[3]]
+		at := pos
+		at.Column += len([]rune(text[:match[0]]))
+		if !variableName.MatchString(name) {
+			return "", Errorf(at, source, "invalid variable name [%s]", name)
+		}
+		value, found := s.Lookup(name)
+		if !found {
+			return "", Errorf(at, source, "the variable [%s] is not defined", name)
+		}
+		expanded.WriteString(value)
+	}
+	expanded.WriteString(text[last:])
+	return expanded.String(), nil
+}
+
+// ExpandArgs : Replaces the references of the arguments of an action line, like @see Expand. The arguments of the
+// [command] lines are shell commands: there, only the references to the defined variables are replaced, and the other
+// references (e.g. [${HOME}] or [${VAR:-x}]) and the [$$] are left to the shell
+func (s *Scope) ExpandArgs(action *Action) (string, *Error) {
+	if action.Keyword != "COMMAND" {
+		return s.Expand(action.Args, action.ArgsPos, action.Text)
+	}
+	return reference.ReplaceAllStringFunc(action.Args, func(match string) string {
+		name := strings.TrimSuffix(strings.TrimPrefix(match, "${"), "}")
+		if match == "$$" || !variableName.MatchString(name) {
+			return match
+		}
+		if value, found := s.Lookup(name); found {
+			return value
+		}
+		return match
+	}), nil
+}
+
+// References : Tells if any statement of the file, including the ones of the blocks, uses the given variable
+func (f *File) References(name string) bool {
+	found := false
+	Walk(f.Statements, func(statement Statement) {
+		var text string
+		switch s := statement.(type) {
+		case *Action:
+			text = s.Args
+		case *Set:
+			text = s.Value
+		case *If:
+			if s.Condition == nil {
+				return
+			}
+			if s.Condition.Subject != "" && s.Condition.Variable() == name {
+				found = true
+			}
+			text = s.Condition.Value
+		default:
+			return
+		}
+		for _, referenced := range referencedNames(text) {
+			if referenced == name {
+				found = true
+			}
+		}
+	})
+	return found
+}
+
+// referencedNames : Returns the names of the variables referenced by a text, in order
+func referencedNames(text string) []string {
+	var names []string
+	for _, match := range reference.FindAllStringSubmatch(text, -1) {
+		if len(match[1]) != 0 {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,30 @@
+package lbconfig
+
+import (
+	"io"
+	"io/ioutil"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+)
+
+// Convert : Translates the configuration file given to the [convert] command, from the line format to a structured
+// format or the other way round, and writes it to the requested output file or, by default, to the given writer
+func (l *AppLauncher) Convert(out io.Writer) error {
+	options := l.AppOptions.Convert
+	format := options.Format
+	if len(format) == 0 {
+		format = config.FormatYAML
+		if config.IsStructured(options.Args.File) {
+			format = config.FormatConf
+		}
+	}
+	converted, err := config.Convert(options.Args.File, format)
+	if err != nil {
+		return err
+	}
+	if len(options.Output) != 0 {
+		return ioutil.WriteFile(options.Output, converted, 0644)
+	}
+	_, err = out.Write(converted)
+	return err
+}
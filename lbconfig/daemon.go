@@ -0,0 +1,160 @@
+package lbconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// Snapshot : Result of a complete evaluation of all the configuration files, as cached by the @see Daemon
+type Snapshot struct {
+	MetricType, MetricValue, PostErmis string
+	Mappings                           []*mapping.ConfigurationMapping
+	Err                                error
+	Timestamp                          time.Time
+	Duration                           time.Duration
+}
+
+// Age : Returns the time elapsed since the snapshot was taken
+func (s *Snapshot) Age() time.Duration {
+	return time.Since(s.Timestamp)
+}
+
+// Output : Returns the formatted output of the snapshot, in the same format as @see AppLauncher.Output
+func (s *Snapshot) Output(oid string) string {
+	return fmt.Sprintf("%s\n%s\n%s\n", oid, s.MetricType, s.MetricValue)
+}
+
+// Daemon : Helper struct that keeps re-evaluating the configuration files of an @see AppLauncher on a fixed interval
+// and serves the polls from the latest cached evaluation
+type Daemon struct {
+	launcher *AppLauncher
+	interval time.Duration
+	maxAge   time.Duration
+
+	// runMutex serialises the evaluations, since the @see AppLauncher stores its output in its own fields
+	runMutex sync.Mutex
+	mutex    sync.RWMutex
+	snapshot *Snapshot
+}
+
+// NewDaemon : Factory-pattern function that creates and returns a new @see Daemon struct instance pointer for the
+// given launcher. The launcher application arguments need to be parsed beforehand
+func NewDaemon(launcher *AppLauncher) *Daemon {
+	return &Daemon{
+		launcher: launcher,
+		interval: launcher.AppOptions.DaemonConfiguration.Interval,
+		maxAge:   launcher.AppOptions.DaemonConfiguration.MaxAge,
+	}
+}
+
+// Refresh : Evaluates all the configuration files and replaces the cached snapshot with the result
+func (d *Daemon) Refresh() *Snapshot {
+	d.runMutex.Lock()
+	defer d.runMutex.Unlock()
+	return d.refresh()
+}
+
+// refresh : Same as @see Refresh, but the caller needs to hold the runMutex
+func (d *Daemon) refresh() *Snapshot {
+	start := time.Now()
+	err := d.launcher.Run()
+	snapshot := &Snapshot{
+		MetricType:  d.launcher.MetricType,
+		MetricValue: d.launcher.MetricValue,
+		PostErmis:   d.launcher.PostErmis,
+		Mappings:    d.launcher.lbConfMappings,
+		Err:         err,
+		Timestamp:   start,
+		Duration:    time.Since(start),
+	}
+	if err != nil {
+		logger.WithError(err).Error("The periodic evaluation of the configuration files failed")
+	}
+	logger.WithField("RUNTIME", snapshot.Duration.String()).Debugf("Cached the metric [%s]", snapshot.MetricValue)
+
+	d.mutex.Lock()
+	d.snapshot = snapshot
+	d.mutex.Unlock()
+	return snapshot
+}
+
+// Latest : Returns the cached snapshot. If there is none yet, or if it is older than the configured maximum age, the
+// configuration files are evaluated again before returning
+func (d *Daemon) Latest() *Snapshot {
+	if snapshot := d.cached(); snapshot != nil {
+		return snapshot
+	}
+
+	d.runMutex.Lock()
+	defer d.runMutex.Unlock()
+	// Another caller might have refreshed the cache while waiting for the lock
+	if snapshot := d.cached(); snapshot != nil {
+		return snapshot
+	}
+	logger.Debug("The cached evaluation expired. Evaluating the configuration files again...")
+	return d.refresh()
+}
+
+// Snapshot : Returns the cached snapshot regardless of its age, without evaluating the configuration files. Returns nil
+// if no evaluation finished yet
+func (d *Daemon) Snapshot() *Snapshot {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.snapshot
+}
+
+// cached : Returns the cached snapshot if it is still within the maximum age, nil otherwise
+func (d *Daemon) cached() *Snapshot {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.snapshot == nil || d.snapshot.Age() > d.maxAge {
+		return nil
+	}
+	return d.snapshot
+}
+
+// Start : Evaluates the configuration files every interval until the stop channel is closed. This function blocks,
+// so it should normally be called in its own goroutine
+func (d *Daemon) Start(stop <-chan struct{}) {
+	logger.Infof("Starting the periodic evaluation with an interval of [%s] and a maximum age of [%s]",
+		d.interval.String(), d.maxAge.String())
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		snapshot := d.Refresh()
+		if snapshot.Err == nil && d.launcher.AppOptions.LbPostFile != "" {
+			d.launcher.PostToErmis(d.launcher.AppOptions.LbPostFile)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			logger.Info("Stopped the periodic evaluation")
+			return
+		}
+	}
+}
+
+// Serve : Answers every line received from the given reader (a poll) with the output of the latest snapshot, until
+// the reader is closed
+func (d *Daemon) Serve(in io.Reader, out io.Writer, oid string) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		snapshot := d.Latest()
+		output := snapshot.Output(oid)
+		if len(snapshot.MetricValue) == 0 {
+			// No metric could be calculated (e.g. the configuration files are missing)
+			output = "NONE\n"
+		}
+		if _, err := io.WriteString(out, output); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
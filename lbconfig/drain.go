@@ -0,0 +1,88 @@
+package lbconfig
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// drainCode : The failure code of the aliases excluded at the end of their drain
+const drainCode = 20
+
+// drain : Raises the load of the aliases being drained, linearly from zero to the configured extra load, and excludes
+// them at the end of the drain. An alias is drained by the file [drain.<alias>] of the drain directory, and all the
+// aliases by the file [drain]. The drain starts at the modification time of the file, and lasts the duration it holds.
+// An empty file excludes the aliases at once. The default configuration file is evaluated for each alias when one of
+// them has its own drain file (@see mapping.ReadLBConfigFiles), so that the drain does not affect the other aliases
+func (l *AppLauncher) drain(lbConfMappings []*mapping.ConfigurationMapping, now time.Time) {
+	options := l.AppOptions.DrainConfiguration
+	for _, cm := range lbConfMappings {
+		if cm.MetricValue < 0 {
+			continue
+		}
+		paths := []string{mapping.DrainFile(options.Dir, "")}
+		for _, alias := range cm.AliasNames {
+			paths = append(paths, mapping.DrainFile(options.Dir, alias))
+		}
+		// The most advanced drain applies
+		progress, path := -1., ""
+		for _, filePath := range paths {
+			if fileProgress, found := drainProgress(filePath, now); found && fileProgress > progress {
+				progress, path = fileProgress, filePath
+			}
+		}
+		if progress < 0 {
+			continue
+		}
+
+		if progress >= 1 {
+			logger.Debugf("Excluding the aliases [%v] at the end of the drain [%s]", cm.AliasNames, path)
+			cm.MetricValue, cm.FailureCode = -drainCode, drainCode
+			continue
+		}
+		extra := int(math.Round(float64(options.Extra) * progress))
+		logger.Debugf("Raising the load of the aliases [%v] by [%d] for the drain [%s]", cm.AliasNames, extra, path)
+		if extra > 0 {
+			raiseLoad(cm, "drain", extra)
+		}
+	}
+}
+
+// drainProgress : Returns the progress of the drain of the given file, from 0 at its start to 1 at its end, and whether
+// the file exists. A file whose duration cannot be parsed ends the drain at once
+func drainProgress(path string, now time.Time) (float64, bool) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, false
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("Unable to access the drain file [%s]. Draining at once", path)
+		return 1, true
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.WithError(err).Errorf("Unable to read the drain file [%s]. Draining at once", path)
+		return 1, true
+	}
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return 1, true
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(string(content)))
+	if err != nil {
+		logger.WithError(err).Errorf("Invalid duration in the drain file [%s]. Draining at once", path)
+		return 1, true
+	}
+	if duration <= 0 {
+		return 1, true
+	}
+	elapsed := now.Sub(info.ModTime())
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(elapsed)/float64(duration), 1), true
+}
@@ -113,7 +113,7 @@ func (l *AppLauncher) PostToErmis(configpath string) (int, error) {
 		logger.Error(err)
 	}
 	if err := conn.initConnection(); err != nil {
-		logger.Errorf("Error while initiating the connection %v , error:", conn.URL, err.Error())
+		logger.Errorf("Error while initiating the connection %v , error: %v", conn.URL, err.Error())
 	}
     //split the calculated alias1:load1,,,aliasN:loadN string
 	aliasesSplitted := strings.Split(l.PostErmis, ",")
@@ -204,7 +204,7 @@ func (c *ClientAuth)verifyConfigs(lbaliases []string) int {
 	for _, v := range c.Status{
 		 //make sure all aliases have secrets defined
 		 if v.Secret == ""{
-			logger.Debug("missing secret for alias %v in lbpost.yaml file",v.AliasName)
+			logger.Debugf("missing secret for alias %v in lbpost.yaml file",v.AliasName)
 			return 1
 		}
 		//extract the declared names in lbpost.yaml
@@ -0,0 +1,145 @@
+package lbconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// ExplainReport : JSON representation of the evaluation trace of a configuration file
+type ExplainReport struct {
+	ConfigFile  string       `json:"config_file"`
+	Fragments   []string     `json:"fragments,omitempty"`
+	Aliases     []string     `json:"aliases"`
+	MetricValue int          `json:"metric_value"`
+	FailureCode int          `json:"failure_code"`
+	Formula     string       `json:"formula,omitempty"`
+	Duration    string       `json:"duration"`
+	Error       string       `json:"error,omitempty"`
+	Lines       []LineStatus `json:"lines"`
+}
+
+// NewExplainReport : Creates the evaluation trace of an evaluated configuration mapping
+func NewExplainReport(cm *mapping.ConfigurationMapping) *ExplainReport {
+	report := &ExplainReport{
+		ConfigFile:  cm.ConfigFilePath,
+		Fragments:   cm.Fragments,
+		Aliases:     cm.AliasNames,
+		MetricValue: cm.MetricValue,
+		FailureCode: cm.FailureCode,
+		Formula:     cm.Formula,
+		Duration:    cm.Duration.String(),
+		Error:       errorString(cm.Err),
+		Lines:       []LineStatus{},
+	}
+	for _, result := range cm.Results {
+		report.Lines = append(report.Lines, newLineStatus(result))
+	}
+	return report
+}
+
+// Explain : Evaluates the configuration files (only the one of the requested alias, if any) and writes the result of
+// every line to the given writer, in the requested format
+func (l *AppLauncher) Explain(out io.Writer) error {
+	options := l.AppOptions.Explain
+	lbConfMappings, err := mapping.ReadLBConfigFiles(l.AppOptions)
+	if err != nil {
+		return err
+	}
+
+	var selected []*mapping.ConfigurationMapping
+	for _, cm := range lbConfMappings {
+		if options.Alias == "" || hasAlias(cm, options.Alias) {
+			selected = append(selected, cm)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("the alias [%s] is not configured", options.Alias)
+	}
+	settings := evaluation{
+		timeout:   l.AppOptions.ExecutionConfiguration.MetricTimeout,
+		keepGoing: options.KeepGoing,
+		cache:     newResultCache(),
+	}
+	l.forEachMapping(selected, func(i int, cm *mapping.ConfigurationMapping) {
+		// The errors are part of the report
+		_ = evaluate(cm, settings)
+	})
+	var reports []*ExplainReport
+	for _, cm := range selected {
+		reports = append(reports, NewExplainReport(cm))
+	}
+
+	if options.Format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+	for i, report := range reports {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		if err := report.writeTable(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTable : Writes the evaluation trace as a human-readable table
+func (r *ExplainReport) writeTable(out io.Writer) error {
+	fmt.Fprintf(out, "Configuration file [%s] for the aliases [%s]\n", r.ConfigFile, strings.Join(r.Aliases, ", "))
+	if len(r.Fragments) != 0 {
+		fmt.Fprintf(out, "Drop-in fragments [%s]\n", strings.Join(r.Fragments, ", "))
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "LINE\tKIND\tKEYWORD\tRESULT\tTOTAL\tSTATUS\tTEXT\tERROR")
+	for _, line := range r.Lines {
+		status := "ok"
+		if line.Excluded {
+			status = "EXCLUDED"
+		} else if line.Penalty != 0 {
+			status = fmt.Sprintf("PENALIZED (+%d)", line.Penalty)
+		} else if len(line.Error) != 0 || line.Result < 0 {
+			status = "FAILED"
+		}
+		if line.Cached {
+			status += " (cached)"
+		}
+		// The lines of the fragments and of the included files are prefixed with the name of their file
+		number := strconv.Itoa(line.Number)
+		if line.File != r.ConfigFile {
+			number = filepath.Base(line.File) + ":" + number
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n", number, line.Kind, line.Action, line.Result,
+			line.Total, status, strings.TrimSpace(line.Line), line.Error)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	if len(r.Formula) != 0 {
+		fmt.Fprintf(out, "Loads aggregated as [%s]\n", r.Formula)
+	}
+	if r.FailureCode != 0 {
+		fmt.Fprintf(out, "Metric value [%d] (failure code [%d]) after [%s]\n", r.MetricValue, r.FailureCode, r.Duration)
+	} else {
+		fmt.Fprintf(out, "Metric value [%d] after [%s]\n", r.MetricValue, r.Duration)
+	}
+	return nil
+}
+
+// hasAlias : Checks if the configuration mapping applies to the given alias
+func hasAlias(cm *mapping.ConfigurationMapping, alias string) bool {
+	for _, aliasName := range cm.AliasNames {
+		if aliasName == alias {
+			return true
+		}
+	}
+	return false
+}
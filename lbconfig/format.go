@@ -0,0 +1,113 @@
+package lbconfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// FormatFile : Returns the canonical form of a configuration file in the line format (see @see config.Format). The
+// arguments of the CLIs that have a canonical form are rewritten (see @see ArgumentsFormatter), the other ones are kept
+// as written. The files with errors are not formatted
+func FormatFile(path string) ([]byte, error) {
+	if config.IsStructured(path) {
+		return nil, fmt.Errorf("the structured configuration file [%s] cannot be formatted", path)
+	}
+	file, err := config.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Format(file, formatArguments), nil
+}
+
+// formatArguments : Returns the canonical form of the arguments of an action line
+func formatArguments(action *config.Action) string {
+	args := strings.TrimSpace(action.Args)
+	expression, err := resolveAction(action)
+	if err != nil {
+		return args
+	}
+	if formatter, ok := expression.cli.(ArgumentsFormatter); ok {
+		if formatted, formatErr := formatter.FormatArguments(args); formatErr == nil {
+			return formatted
+		}
+	}
+	return args
+}
+
+// Format : Formats the files given to the [fmt] command or, by default, all the configuration files in the line
+// format, with their fragments and the files they include. The files that change are rewritten, unless only a check
+// was requested. Their paths are written to the given writer. Returns true if all the files were already formatted
+func (l *AppLauncher) Format(out io.Writer) (bool, error) {
+	options := l.AppOptions.Fmt
+	paths := options.Args.Files
+	if len(paths) == 0 {
+		var err error
+		if paths, err = l.lineFormatFiles(); err != nil {
+			return false, err
+		}
+	}
+
+	formatted := true
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		canonical, err := FormatFile(path)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(src, canonical) {
+			continue
+		}
+		formatted = false
+		fmt.Fprintln(out, path)
+		if options.Check {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if err = ioutil.WriteFile(path, canonical, info.Mode()); err != nil {
+			return false, err
+		}
+	}
+	return formatted, nil
+}
+
+// lineFormatFiles : Returns the paths of the configuration files in the line format, of their fragments and of the
+// files they include, sorted
+func (l *AppLauncher) lineFormatFiles() ([]string, error) {
+	lbConfMappings, err := mapping.ReadLBConfigFiles(l.AppOptions)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for _, cm := range lbConfMappings {
+		file, _ := config.Load(cm.ConfigFilePath, cm.Fragments...)
+		candidates := append([]string{cm.ConfigFilePath}, cm.Fragments...)
+		if file != nil {
+			candidates = append(candidates, file.Includes...)
+		}
+		for _, path := range candidates {
+			if _, statErr := os.Stat(path); statErr == nil && !config.IsStructured(path) {
+				found[path] = true
+			}
+		}
+	}
+	var paths []string
+	for path := range found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
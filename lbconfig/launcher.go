@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	nested "github.com/antonfisher/nested-logrus-formatter"
 	fluentd "github.com/joonix/log"
 	logger "github.com/sirupsen/logrus"
 	"gitlab.cern.ch/lb-experts/golbclient/helpers/appSettings"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/metrics"
 )
 
 // AppLauncher : Helper struct that encapsulates the logic of running [lbclient]
@@ -52,7 +55,12 @@ func (l *AppLauncher) ApplyLoggerSettings() error {
 	}
 
 	logger.SetReportCaller(true)
-	logger.SetOutput(os.Stdout)
+	if l.AppOptions.Daemon || l.AppOptions.PassPersist {
+		// Stdout is reserved for the answers to the polls
+		logger.SetOutput(os.Stderr)
+	} else {
+		logger.SetOutput(os.Stdout)
+	}
 
 	switch strings.ToLower(l.AppOptions.LoggerMode) {
 	case "fluentd":
@@ -75,32 +83,139 @@ func (l *AppLauncher) ApplyLoggerSettings() error {
 // 	2 - Once this function exits, the correlated @see AppLauncher instance gets its MetricType and MetricValue fields
 //      populated and ready to be used
 func (l *AppLauncher) Run() error {
+	start := time.Now()
+	// Discard the output of a previous run (e.g. when running as a daemon)
+	l.MetricType, l.MetricValue, l.PostErmis, l.lbConfMappings = "", "", "", nil
+
 	lbConfMappings, err := mapping.ReadLBConfigFiles(l.AppOptions)
 	if err != nil {
 		return err
 	}
+	l.lbConfMappings = lbConfMappings
 
-	// Application output
-	var appOutput bytes.Buffer
-	var returnCode error
 	// Evaluate for each of the configuration files found
-	for _, confMapping := range lbConfMappings {
+	errs := make([]error, len(lbConfMappings))
+	settings := evaluation{
+		timeout:     l.AppOptions.ExecutionConfiguration.MetricTimeout,
+		checkConfig: len(l.AppOptions.ExecutionConfiguration.CheckConfigFilePath) != 0,
+		cache:       newResultCache(),
+	}
+	var state *State
+	unlock := func() {}
+	if !settings.checkConfig {
+		state, unlock = l.readState()
+		settings.dampener = l.newDampener(state)
+	}
+	l.forEachMapping(lbConfMappings, func(i int, confMapping *mapping.ConfigurationMapping) {
 		logger.Tracef("Processing configuration file [%s] for aliases [%v]", confMapping.ConfigFilePath, confMapping.AliasNames)
-		err := Evaluate(confMapping,
-			l.AppOptions.ExecutionConfiguration.MetricTimeout, len(l.AppOptions.ExecutionConfiguration.CheckConfigFilePath) != 0)
+		errs[i] = evaluate(confMapping, settings)
+	})
+	if !settings.checkConfig {
+		l.drain(lbConfMappings, time.Now())
+	}
+	if state != nil {
+		l.slowStart(state, lbConfMappings, time.Now())
+		state.prune()
+		path := l.AppOptions.StateConfiguration.File
+		if err = state.Write(path); err != nil {
+			logger.WithError(err).Errorf("Unable to write the state file [%s]", path)
+		}
+	}
+	unlock()
+
+	// Application output, in the order of the configuration files
+	var appOutput bytes.Buffer
+	var returnCode error
+	for i, confMapping := range lbConfMappings {
 		/* Abort if an error occurs */
-		if err != nil {
+		if errs[i] != nil {
 			logger.Warnf("The evaluation of configuration file [%s] failed.", confMapping.ConfigFilePath)
-			returnCode = err
+			returnCode = errs[i]
 		}
 		appOutput.WriteString(confMapping.String() + ",")
 	}
 
 	l.MetricType, l.MetricValue, l.PostErmis = mapping.GetReturnCode(appOutput, lbConfMappings)
 	logger.Debugf("metric = [%s]", l.MetricValue)
+
+	l.exportMetrics(start)
 	return returnCode
 }
 
+// forEachMapping : Calls the given function for every configuration mapping, running at most the configured amount of
+// workers concurrently. Returns once all the calls returned
+func (l *AppLauncher) forEachMapping(lbConfMappings []*mapping.ConfigurationMapping,
+	f func(i int, cm *mapping.ConfigurationMapping)) {
+	workers := l.AppOptions.ExecutionConfiguration.Workers
+	if workers > len(lbConfMappings) {
+		workers = len(lbConfMappings)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f(i, lbConfMappings[i])
+			}
+		}()
+	}
+	for i := range lbConfMappings {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// exportMetrics : Updates the Prometheus metrics with the results of the evaluation that started at the given time,
+// and writes them to the textfile if requested
+func (l *AppLauncher) exportMetrics(start time.Time) {
+	metrics.EvaluationTimestamp.Set(float64(start.Unix()))
+	metrics.EvaluationDuration.Set(time.Since(start).Seconds())
+	// Forget the aliases and checks that are no longer configured
+	metrics.AliasLoad.Reset()
+	metrics.CheckPassed.Reset()
+	for _, cm := range l.lbConfMappings {
+		for _, result := range cm.Results {
+			if result.Action == "" || result.Cached {
+				// The line could not be parsed, or did not run since its result was reused
+				continue
+			}
+			metrics.CheckDuration.Observe(result.Duration.Seconds(), result.Action)
+			if result.TimedOut {
+				metrics.CheckTimeouts.Inc(result.Action)
+			}
+		}
+		for _, alias := range cm.AliasNames {
+			metrics.AliasLoad.Set(float64(cm.MetricValue), alias)
+			for _, result := range cm.Results {
+				if result.Action == "" {
+					continue
+				}
+				passed := 0.
+				if result.Err == nil && result.Value >= 0 {
+					passed = 1
+				}
+				// The line tells apart the lines with the same keyword
+				metrics.CheckPassed.Set(passed, alias, result.Action, fmt.Sprintf("%s:%d", result.File, result.Number))
+			}
+		}
+	}
+
+	if path := l.AppOptions.MetricsConfiguration.TextFile; path != "" {
+		if err := metrics.Default.WriteTextFile(path); err != nil {
+			logger.WithError(err).Errorf("Unable to write the metrics to the file [%s]", path)
+		}
+	}
+}
+
+// Mappings : Returns the configuration mappings evaluated by the last call to @see Run
+func (l *AppLauncher) Mappings() []*mapping.ConfigurationMapping {
+	return l.lbConfMappings
+}
+
 // Output : Returns the formatted output of the @see AppLauncher instance
 func (l *AppLauncher) Output(oid string) string {
 	return fmt.Sprintf("%s\n%s\n%s\n", oid, l.MetricType, l.MetricValue)
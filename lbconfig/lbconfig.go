@@ -3,13 +3,16 @@
 package lbconfig
 
 import (
+	"context"
 	"fmt"
 	logger "github.com/sirupsen/logrus"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/checks"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/checks/parameterized"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/filehandler"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/timer"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -17,15 +20,17 @@ import (
 	"time"
 )
 
-// ExpressionCode : return value for the CLI calls
+// ExpressionCode : return value for the CLI calls. The result of the CLIs that depend on the alias (e.g. the nologin
+// files) is only shared between the configuration files of the same alias
 type ExpressionCode struct {
-	code int
-	cli  CLI
+	code           int
+	cli            CLI
+	aliasDependent bool
 }
 
 // @TODO: add values to the wiki page: http://configdocs.web.cern.ch/configdocs/dnslb/lbclientcodes.html
 var allLBExpressions = map[string]ExpressionCode{
-	"NOLOGIN": {code: 1, cli: checks.NoLogin{}},
+	"NOLOGIN": {code: 1, cli: checks.NoLogin{}, aliasDependent: true},
 	"TMPFULL": {code: 6, cli: checks.TmpFull{}},
 	"SSHDAEMON": {code: 7, cli: checks.DaemonListening{Metric: `{"port": 22, 	"protocol": "tcp", "ip":["ipv4", "ipv6"]}`}},
 	"WEBDAEMON": {code: 8, cli: checks.DaemonListening{Metric: `{"port": 80, 	"protocol": "tcp", "ip":["ipv4", "ipv6"]}`}},
@@ -52,8 +57,40 @@ var allLBExpressions = map[string]ExpressionCode{
 	And here we add the methods of the class
 */
 
-// Evaluate : Evaluates a [lbalias] entry
+// evaluation : Settings of the evaluation of a [lbalias] entry
+type evaluation struct {
+	timeout     time.Duration
+	checkConfig bool
+	// keepGoing continues the evaluation past the failing lines
+	keepGoing bool
+	// cache shares the results of the action lines between the configuration files of the same run. Optional
+	cache *resultCache
+	// dampener keeps the checks that flap from excluding the node at every failure. Optional
+	dampener *dampener
+}
+
+// Evaluate : Evaluates a [lbalias] entry. Besides the metric value, the result of every action line, the failure code
+// and the runtime are kept in the configuration mapping
 func Evaluate(cm *mapping.ConfigurationMapping, timeout time.Duration, checkConfig bool) error {
+	return evaluate(cm, evaluation{timeout: timeout, checkConfig: checkConfig})
+}
+
+// Explain : Evaluates a [lbalias] entry like @see Evaluate. If keepGoing is set, the evaluation does not stop at the
+// first failing line, so that all the problems show up in the results of the configuration mapping. The metric value
+// and the failure code are still the ones of the first failing line
+func Explain(cm *mapping.ConfigurationMapping, timeout time.Duration, keepGoing bool) error {
+	return evaluate(cm, evaluation{timeout: timeout, keepGoing: keepGoing})
+}
+
+// evaluate : Evaluates a [lbalias] entry. See @see Evaluate and @see Explain
+func evaluate(cm *mapping.ConfigurationMapping, settings evaluation) (err error) {
+	timeout, checkConfig, keepGoing := settings.timeout, settings.checkConfig, settings.keepGoing
+	start := time.Now()
+	cm.Results, cm.Formula, cm.FailureCode = nil, "", 0
+	defer func() {
+		cm.Duration, cm.Err = time.Since(start), err
+	}()
+
 	contextLogger := logger.WithFields(logger.Fields{
 		"EVALUATION":   "LOADING",
 		"CFG_PATH":     cm.ConfigFilePath,
@@ -63,80 +100,274 @@ func Evaluate(cm *mapping.ConfigurationMapping, timeout time.Duration, checkConf
 
 	contextLogger.Debug("Started the evaluation of the the configuration file...")
 
-	// Create a string array containing all the checksToExecute to be performed
-	var checksToExecute []string
-	for key := range allLBExpressions {
-		if key != "CONSTANT" {
-			checksToExecute = append(checksToExecute, fmt.Sprintf("(%s)", key))
-		}
+	// Attempt to read and parse the configuration file, its drop-in fragments and the files they include. The lines
+	// that cannot be parsed are reported once reached
+	file, err := config.Load(cm.ConfigFilePath, cm.Fragments...)
+	if file == nil {
+		contextLogger.Errorf("Fatal error when attempting to open the alias configuration file [%s]", err.Error())
+		return err
 	}
+	err = nil
+	contextLogger.Debugf("Successfully parsed the alias configuration file [%v] with the fragments [%v]",
+		cm.ConfigFilePath, cm.Fragments)
 
-	// Attempt to read the configuration file
+	// loads aggregates the load lines so far, and excluded is set once a line excludes the node from the alias
+	loads, excluded := newAggregator(file), false
+	record := func(result mapping.LineResult) {
+		result.Total = loads.value()
+		cm.Results = append(cm.Results, result)
+	}
+	exclude := func(result *mapping.LineResult, metricValue, failureCode int) {
+		if !excluded {
+			excluded, result.Excluded = true, true
+			cm.MetricValue, cm.FailureCode = metricValue, failureCode
+		}
+	}
+	// invalid : Records a line that could not be parsed. Returns true if the evaluation should stop
+	invalid := func(result mapping.LineResult, lineErr error) bool {
+		result.Err = lineErr
+		exclude(&result, -1, 0)
+		record(result)
+		if err == nil {
+			err = lineErr
+		}
+		return !keepGoing
+	}
 
-	lines, err := filehandler.ReadAllLinesFromFile(cm.ConfigFilePath)
-	if err != nil {
-		contextLogger.Errorf("Fatal error when attempting to open the alias configuration file [%s]", err.Error())
-		return err
+	// The optional time budget of the whole file
+	var deadline time.Time
+	if file.Budget != nil {
+		deadline = start.Add(file.Budget.Duration)
 	}
-	// Read the configuration file using the scanner API
-	contextLogger.Debugf("Successfully opened the alias configuration file [%v]", cm.ConfigFilePath)
 
-	// Detect all comments
-	comment := regexp.MustCompile("^[ \t]*(#.*)?$")
-	// Detect all actions (checks or loads) to be made
-	checksFormat := "^[ ]*CHECK (" + strings.Join(checksToExecute, "|") + ")"
-	loadsFormat := "^[ ]*LOAD ((LEMON)|(COLLECTD)|(CONSTANT))( )*(.*)"
-	actions := regexp.MustCompile(fmt.Sprintf(`(?i)((%s)|(%s))`, checksFormat, loadsFormat))
+	// The variables are visible from their definition on
+	scope := config.NewScope(facts(cm))
 
-	// Read the configuration file line-by-line
-	for _, line := range lines {
-		if comment.MatchString(line) {
+	statements := newCursor(file.Statements)
+	for statement, more := statements.next(); more; statement, more = statements.next() {
+		result := mapping.LineResult{File: statement.Pos().File, Number: statement.Pos().Line, Line: statement.Source()}
+		var action *config.Action
+		switch s := statement.(type) {
+		case *config.Action:
+			args, expandErr := scope.ExpandArgs(s)
+			if expandErr != nil {
+				if invalid(result, expandErr) {
+					return err
+				}
+				continue
+			}
+			expanded := *s
+			action, expanded.Args = &expanded, args
+		case *config.Set:
+			value, expandErr := scope.Expand(s.Value, s.ValuePos, s.Text)
+			if expandErr != nil {
+				if invalid(result, expandErr) {
+					return err
+				}
+				continue
+			}
+			scope.Set(s.Name, value)
+			continue
+		case *config.If:
+			// The syntax errors fail the block, even if they are in the branch that is not taken
+			if blockErr := blockError(s); blockErr != nil {
+				if invalid(result, blockErr) {
+					return err
+				}
+				continue
+			}
+			matched, conditionErr := holds(s, scope)
+			if conditionErr != nil {
+				if invalid(result, conditionErr) {
+					return err
+				}
+				continue
+			}
+			contextLogger.WithField("SOURCE", s.Pos().String()).Tracef("The condition of the line [%s] is [%v]",
+				s.Text, matched)
+			if matched {
+				statements.enter(s.Then)
+			} else {
+				statements.enter(s.Else)
+			}
+			continue
+		case *config.BadStatement:
+			if invalid(result, s.Err) {
+				return err
+			}
+			continue
+		default:
 			continue
 		}
-		foundActions := actions.FindStringSubmatch(line)
-		if len(foundActions) > 0 {
-			/********************************** ACTIONS **********************************/
-			myAction := strings.ToUpper(strings.Split(line, " ")[1])
-			if _, ok := allLBExpressions[myAction]; !ok {
-				return fmt.Errorf("the given action (check or load) metric [%s] is not supported", myAction)
-			}
-			isLoad := regexp.MustCompile(`(?i)^LOAD`).MatchString(line)
-			negRet := -allLBExpressions[myAction].code
-			ret, err := timer.ExecuteWithTimeoutRInt(timeout, allLBExpressions[myAction].cli.Run,
-				contextLogger.WithFields(logger.Fields{
-					"CLI":        myAction,
-					"EVALUATION": "ONGOING",
-				}), line, cm.AliasNames, cm.Default)
-
-			if err != nil {
-				cm.MetricValue = negRet
+
+		/********************************** ACTIONS **********************************/
+		expression, actionErr := resolveAction(action)
+		if actionErr != nil {
+			if invalid(result, actionErr) {
 				return err
 			}
-			if ret < 0 && !checkConfig {
-				cm.MetricValue = negRet
-				return nil
+			continue
+		}
+		myAction := action.Keyword
+		code := expression.code
+		actionStart := time.Now()
+
+		// The line timeout overrides the global one, and both are limited by the time left in the file budget
+		actionTimeout := timeout
+		if action.Timeout > 0 {
+			actionTimeout = action.Timeout
+		}
+		var ret int
+		lineTimeout, clamped := actionTimeout, false
+		if !deadline.IsZero() && time.Until(deadline) < actionTimeout {
+			actionTimeout, clamped = time.Until(deadline), true
+		}
+		if actionTimeout <= 0 {
+			ret, actionErr = -1, fmt.Errorf("the time budget of the configuration file [%s] was exhausted before "+
+				"the line [%s]", cm.ConfigFilePath, action.Text)
+		} else {
+			fields := logger.Fields{
+				"CLI":        myAction,
+				"EVALUATION": "ONGOING",
+				"SOURCE":     action.Pos().String(),
+			}
+			// The checks and loads of the structured configuration files can be named
+			if len(action.Name) != 0 {
+				fields["NAME"] = action.Name
+			}
+			run := func() (int, error) {
+				// The context is cancelled on timeout, which kills the processes started by the CLI
+				return timer.ExecuteWithContext(context.Background(), actionTimeout, myAction,
+					func(ctx context.Context) (int, error) {
+						return expression.cli.Run(ctx, contextLogger.WithFields(fields), action.Args, cm.AliasNames,
+							cm.Default)
+					})
 			}
-			if isLoad {
-				cm.MetricValue += ret
+			// The results run with a timeout shortened by the budget are not shared, since they may have timed out
+			if settings.cache != nil && !clamped {
+				key := cacheKey(action, lineTimeout, expression.aliasDependent, cm.AliasNames, cm.Default)
+				ret, actionErr, result.Cached = settings.cache.get(key, run)
+				if result.Cached {
+					contextLogger.WithFields(logger.Fields{"CLI": myAction, "SOURCE": action.Pos().String()}).Debugf(
+						"Reusing the cached result [%d] of the line [%s]", ret, action.Text)
+				}
+			} else {
+				ret, actionErr = run()
 			}
+		}
+		_, timedOut := actionErr.(*timer.TimeoutError)
+		result.Action, result.IsLoad, result.Value, result.Err = myAction, action.IsLoad(), ret, actionErr
+		result.Duration, result.TimedOut, result.IsPenalty = time.Since(actionStart), timedOut, action.IsPenalty()
 
-		} else {
-			// If none of the regexps were found, then it is assumed that there is a user-made mistake in the configuration file
-			cm.MetricValue = -1
-			return fmt.Errorf("unable to parse the configuration metric line [%s]. Stopping execution with "+
-				"code [%d]", line, cm.MetricValue)
+		// The dampening may ignore a failure, or keep a check failing after a success
+		failed := actionErr != nil || ret < 0
+		if settings.dampener != nil && !action.IsLoad() {
+			if dampened := settings.dampener.dampen(cm, action, failed); dampened != failed {
+				contextLogger.WithFields(logger.Fields{"CLI": myAction, "SOURCE": action.Pos().String()}).Debugf(
+					"Dampened the result [%d] of the line [%s]. Considered as failing [%v]", ret, action.Text, dampened)
+				failed, result.Dampened = dampened, true
+			}
+		}
+
+		// The soft checks that fail (or cannot run) add their penalty to the load instead of excluding the node
+		if action.IsPenalty() && failed {
+			if !checkConfig {
+				contextLogger.WithFields(logger.Fields{"CLI": myAction, "SOURCE": action.Pos().String()}).Debugf(
+					"Adding the penalty [%d] of the failing line [%s]", action.Penalty, action.Text)
+				loads.penalize(action.Penalty)
+				result.Penalty = action.Penalty
+			}
+			record(result)
+			continue
+		}
+		// A dampened failure is ignored, while a dampened success still fails like the line did before
+		if result.Dampened && !failed {
+			record(result)
+			continue
 		}
+		if actionErr != nil {
+			exclude(&result, -code, code)
+			record(result)
+			if !keepGoing {
+				return actionErr
+			}
+			if err == nil {
+				err = actionErr
+			}
+			continue
+		}
+		if (ret < 0 || result.Dampened) && !checkConfig {
+			exclude(&result, -code, code)
+			record(result)
+			if !keepGoing {
+				return nil
+			}
+			continue
+		}
+		if action.IsLoad() {
+			loads.add(ret, action.Weight)
+		}
+		record(result)
+	}
+	if excluded {
+		return err
 	}
 
-	if cm.MetricValue == 0 {
+	cm.MetricValue += loads.aggregate()
+	defaulted := cm.MetricValue == 0
+	if defaulted {
 		contextLogger.Infof("No metric value was found. Defaulting to the generic load calculation")
 		cm.MetricValue = defaultLoad()
 	}
+	// The penalties of the soft checks are added to the load, whatever its aggregation is
+	cm.MetricValue += loads.penalty()
+	if cm.Formula = loads.formula(cm.MetricValue, defaulted); len(cm.Formula) != 0 {
+		contextLogger.Debugf("Aggregated the loads with the formula [%s]", cm.Formula)
+	}
 
 	// Log
 	contextLogger.WithField("EVALUATION", "FINISHED").Tracef("Final metric value [%d]", cm.MetricValue)
 
-	return nil
+	return err
+}
+
+// facts : Returns the facts of the host and of the alias that the configuration files can use as variables. The alias
+// is empty when validating a configuration file, and the default configuration file is evaluated separately for every
+// alias when it uses it (see @see mapping.ReadLBConfigFiles)
+func facts(cm *mapping.ConfigurationMapping) map[string]string {
+	hostname, _ := os.Hostname()
+	facts := map[string]string{"HOSTNAME": hostname}
+	if len(cm.AliasNames) <= 1 {
+		facts["ALIAS"] = strings.Join(cm.AliasNames, "")
+	}
+	return facts
+}
+
+// loadExpressions : The CLIs that can be used in a load line
+var loadExpressions = []string{"LEMON", "LEMONLOAD", "COLLECTD", "COLLECTDLOAD", "CONSTANT"}
+
+// resolveAction : Returns the expression of the CLI of an action line, or an error pointing at the keyword if the CLI
+// does not exist or cannot be used in this kind of line
+func resolveAction(action *config.Action) (ExpressionCode, error) {
+	expression, found := allLBExpressions[action.Keyword]
+	if !found {
+		return expression, config.Errorf(action.KeywordPos, action.Text, "the %s [%s] is not supported",
+			action.Kind, strings.ToLower(action.Keyword))
+	}
+	if !action.IsLoad() {
+		if action.Keyword == "CONSTANT" {
+			return expression, config.Errorf(action.KeywordPos, action.Text, "a constant can only be used in a load "+
+				"line (e.g. [load constant 5])")
+		}
+		return expression, nil
+	}
+	for _, load := range loadExpressions {
+		if action.Keyword == load {
+			return expression, nil
+		}
+	}
+	return expression, config.Errorf(action.KeywordPos, action.Text, "the [%s] CLI cannot be used in a load line. "+
+		"Please use one of [%s]", strings.ToLower(action.Keyword), strings.ToLower(strings.Join(loadExpressions, ", ")))
 }
 
 func defaultLoad() int {
@@ -0,0 +1,181 @@
+package lbconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/checks"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/filehandler"
+)
+
+// Lint rules, reported in the [rule] field of the diagnostics
+const (
+	RuleDeprecatedCheck = "deprecated-check"
+	RuleNegativeLoad    = "negative-load"
+	RuleDuplicateCheck  = "duplicate-check"
+	RuleRelativeCommand = "relative-command"
+	RuleMissingSecret   = "missing-secret"
+	RuleUnlistedAlias   = "unlisted-alias"
+)
+
+// Lint : Validates a configuration mapping like @see Validate, and also reports the likely mistakes as warnings: the
+// deprecated checks that always succeed, the load lines that can be negative (and exclude the node), the checks that
+// duplicate an earlier one and the commands given with a relative path
+func Lint(cm *mapping.ConfigurationMapping) []Diagnostic {
+	return validate(cm, true)
+}
+
+// lintAction : Reports the likely mistakes of a check or load line, whose arguments were already expanded
+func (v *validator) lintAction(action *config.Action, args string, expression ExpressionCode) {
+	keyword := strings.ToLower(action.Keyword)
+	if _, ok := expression.cli.(checks.CheckAttribute); ok {
+		v.warn(RuleDeprecatedCheck, config.Errorf(action.KeywordPos, action.Text, "the [%s] %s is deprecated and "+
+			"always succeeds", keyword, action.Kind))
+	}
+	if checker, ok := expression.cli.(NegativeLoadChecker); ok && action.IsLoad() && checker.CanBeNegative(args) {
+		v.warn(RuleNegativeLoad, config.Errorf(action.ArgsPos, action.Text, "the [%s] load can be negative, which "+
+			"excludes the node from the alias", keyword))
+	}
+	if command, ok := expression.cli.(checks.Command); ok {
+		program := command.Program(args)
+		if strings.Contains(program, "/") && !filepath.IsAbs(program) {
+			v.warn(RuleRelativeCommand, config.Errorf(action.ArgsPos, action.Text, "the command [%s] is relative to "+
+				"the working directory of the lbclient. Use an absolute path", program))
+		}
+	}
+	if action.IsLoad() {
+		return
+	}
+	expanded := *action
+	expanded.Args = args
+	// The same check with another timeout is still a duplicate
+	key := cacheKey(&expanded, 0, false, nil, false)
+	if previous, found := v.seen[key]; found {
+		v.warn(RuleDuplicateCheck, config.Errorf(action.Position, action.Text, "the check duplicates the one of "+
+			"the line [%s]", previous.Position))
+		return
+	}
+	v.seen[key] = action
+}
+
+// copySeen : Returns a copy of the checks seen so far, for a branch of a block
+func copySeen(seen map[string]*config.Action) map[string]*config.Action {
+	copied := make(map[string]*config.Action, len(seen))
+	for key, action := range seen {
+		copied[key] = action
+	}
+	return copied
+}
+
+// commonSeen : Returns the checks seen in both branches of a block
+func commonSeen(then, otherwise map[string]*config.Action) map[string]*config.Action {
+	common := make(map[string]*config.Action)
+	for key, action := range then {
+		if _, found := otherwise[key]; found {
+			common[key] = action
+		}
+	}
+	return common
+}
+
+// Lint : Lints all the configuration files of the configuration directory (see @see Lint), and cross-checks them with
+// the lbaliases file and, if given, with the lbpost file: the aliases need a secret to post their load, and the
+// per-alias configuration files should belong to a listed alias. The problems that prevent reading the directory are
+// reported as diagnostics too
+func (l *AppLauncher) Lint() []Diagnostic {
+	options := l.AppOptions
+	var diagnostics []Diagnostic
+	lbConfMappings, err := mapping.ReadLBConfigFiles(options)
+	if err != nil {
+		diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, File: options.LbMetricConfDir,
+			Message: err.Error()})
+	}
+	// The default configuration file may be split per alias, but it only needs to be linted once
+	linted := make(map[string]bool)
+	for _, cm := range lbConfMappings {
+		key := fmt.Sprintf("%s%v", cm.ConfigFilePath, cm.Fragments)
+		if linted[key] {
+			continue
+		}
+		linted[key] = true
+		diagnostics = append(diagnostics, Lint(cm)...)
+	}
+
+	/* The aliases, with the position of their line in the lbaliases file */
+	lines, err := filehandler.ReadAllLinesFromFile(options.LbAliasFile)
+	if err != nil {
+		// Already reported when reading the configuration directory
+		sortDiagnostics(diagnostics)
+		return diagnostics
+	}
+	var aliases []string
+	positions := make(map[string]config.Position)
+	for i, line := range lines {
+		if alias, ok := mapping.ParseAliasLine(line); ok {
+			aliases = append(aliases, alias)
+			positions[alias] = config.Position{File: options.LbAliasFile, Line: i + 1,
+				Column: strings.Index(line, alias) + 1}
+		}
+	}
+
+	for _, cm := range lbConfMappings {
+		if cm.Default {
+			continue
+		}
+		if _, listed := positions[cm.AliasNames[0]]; !listed {
+			diagnostics = append(diagnostics, Diagnostic{Severity: SeverityWarning, File: cm.ConfigFilePath,
+				Message: fmt.Sprintf("the alias [%s] is not listed in the lbaliases file [%s]", cm.AliasNames[0],
+					options.LbAliasFile), Rule: RuleUnlistedAlias})
+		}
+	}
+
+	if len(options.LbPostFile) != 0 {
+		conn, err := getConn(options.LbPostFile)
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, File: options.LbPostFile,
+				Message: fmt.Sprintf("cannot read the lbpost file: %v", err)})
+		} else {
+			secrets := make(map[string]bool)
+			for _, status := range conn.Status {
+				if status != nil && len(status.Secret) != 0 {
+					secrets[status.AliasName] = true
+				}
+			}
+			for _, alias := range aliases {
+				if !secrets[alias] {
+					pos := positions[alias]
+					diagnostic := newDiagnostic(SeverityWarning, config.Errorf(pos, lines[pos.Line-1],
+						"the alias [%s] has no secret in the lbpost file [%s]", alias, options.LbPostFile))
+					diagnostic.Rule = RuleMissingSecret
+					diagnostics = append(diagnostics, diagnostic)
+				}
+			}
+		}
+	}
+	sortDiagnostics(diagnostics)
+	return diagnostics
+}
+
+// WriteDiagnostics : Writes the diagnostics to the given writer, either as text (one diagnostic per line, followed by
+// the source line) or as a JSON array
+func WriteDiagnostics(out io.Writer, diagnostics []Diagnostic, format string) error {
+	if format == "json" {
+		if diagnostics == nil {
+			diagnostics = []Diagnostic{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diagnostics)
+	}
+	for _, diagnostic := range diagnostics {
+		if _, err := fmt.Fprintln(out, diagnostic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
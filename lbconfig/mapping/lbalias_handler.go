@@ -6,22 +6,61 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"gitlab.cern.ch/lb-experts/golbclient/helpers/appSettings"
 
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/filehandler"
 
 	logger "github.com/sirupsen/logrus"
 )
 
+// lbAliasLine : Syntax of the lines of the lbaliases file that declare an alias
+var lbAliasLine = regexp.MustCompile(`^\s*lbalias\s*=\s*(\S+)`)
+
+// LineResult : Result of the evaluation of a single action (check or load) line of a configuration file. Total is the
+// running load after the line, File is the file of the line (the configuration file or one of its fragments), Excluded
+// marks the line that excluded the node from the alias and Cached marks the results reused from the evaluation of
+// another configuration file
+type LineResult struct {
+	File     string
+	Number   int
+	Line     string
+	Action   string
+	IsLoad   bool
+	Value    int
+	Total    int
+	Excluded bool
+	Err      error
+	Duration time.Duration
+	TimedOut bool
+	Cached   bool
+	// IsPenalty marks the soft checks, and Penalty is the load added by the ones that failed
+	IsPenalty bool
+	Penalty   int
+	// Dampened marks the checks whose result was overridden by the dampening of the flapping checks
+	Dampened bool
+}
+
 // ConfigurationMapping : object with the config
 type ConfigurationMapping struct {
 	ConfigFilePath string
-	AliasNames     []string
-	MetricValue    int
+	// Fragments are the drop-in files merged after the configuration file, in lexical order
+	Fragments   []string
+	AliasNames  []string
+	MetricValue int
 	//ChecksDone     map[string]bool
 	Default bool
+	/* Details of the last evaluation */
+	Results []LineResult
+	// Formula is the aggregation of the results of the load lines, e.g. [max(12, 30) = 30]
+	Formula     string
+	FailureCode int
+	Duration    time.Duration
+	Err         error
 }
 
 // NewConfiguration : Creates a Configuration object
@@ -62,25 +101,87 @@ func ReadLBConfigFiles(options appSettings.Options) (confFiles []*ConfigurationM
 
 	tmpConfMap := make(map[string]bool)
 	var defaultMapping *ConfigurationMapping
+	// The drop-in directories, indexed by the alias ("" for the default configuration file)
+	dropInDirs := make(map[string]string)
+	confDir := filepath.Clean(options.LbMetricConfDir)
 
 	/* Read the configuration files */
 	err = filepath.Walk(options.LbMetricConfDir,
 		func(path string, info os.FileInfo, err error) error {
-			if info == nil || info.IsDir() || err != nil {
+			if info == nil || err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if filepath.Dir(path) == confDir {
+					if info.Name() == options.LbMetricDefaultFileName+".d" {
+						dropInDirs[""] = path
+					} else if strings.HasSuffix(info.Name(), ".cern.ch.d") && strings.HasPrefix(info.Name(), "lbclient.conf.") {
+						dropInDirs[strings.TrimSuffix(strings.TrimPrefix(info.Name(), "lbclient.conf."), ".d")] = path
+					}
+				}
 				return nil
 			}
 			logger.Debugf("Checking the file [%v]", path)
-			if info.Name() == options.LbMetricDefaultFileName {
+			if info.Name() == options.LbMetricDefaultFileName || isStructuredDefault(info.Name(), options) {
+				if defaultMapping != nil && filepath.Dir(defaultMapping.ConfigFilePath) == filepath.Dir(path) {
+					return fmt.Errorf("both [%s] and [%s] are the default configuration file",
+						defaultMapping.ConfigFilePath, path)
+				}
 				defaultMapping = NewConfiguration(path)
 				logger.Trace("Added the default")
-			} else if strings.HasSuffix(info.Name(), ".cern.ch") && strings.HasPrefix(info.Name(), "lbclient.conf") {
-				aliasName := strings.TrimSpace(strings.Split(path, "lbclient.conf.")[1])
+			} else if aliasName := structuredAlias(info.Name()); len(aliasName) != 0 ||
+				strings.HasSuffix(info.Name(), ".cern.ch") && strings.HasPrefix(info.Name(), "lbclient.conf") {
+				if len(aliasName) == 0 {
+					aliasName = strings.TrimSpace(strings.Split(path, "lbclient.conf.")[1])
+				}
+				if tmpConfMap[aliasName] && sameDirectory(confFiles, aliasName, path) {
+					return fmt.Errorf("the alias [%s] has more than one configuration file", aliasName)
+				}
 				logger.Tracef("Added config for %v", aliasName)
 				confFiles = append(confFiles, NewConfiguration(path, aliasName))
 				tmpConfMap[aliasName] = true
 			}
 			return nil
 		})
+	if err != nil {
+		return nil, err
+	}
+
+	/* Merge the drop-in fragments. An alias can be configured with its drop-in directory only */
+	added := false
+	for alias, dir := range dropInDirs {
+		fragments, _ := filepath.Glob(filepath.Join(dir, "*.conf"))
+		if len(fragments) == 0 {
+			continue
+		}
+		logger.Tracef("Found the fragments [%v] in the drop-in directory [%s]", fragments, dir)
+		if alias == "" {
+			if defaultMapping == nil {
+				defaultMapping = NewConfiguration(filepath.Join(options.LbMetricConfDir, options.LbMetricDefaultFileName))
+			}
+			defaultMapping.Fragments = fragments
+			continue
+		}
+		if !tmpConfMap[alias] {
+			confFiles = append(confFiles, NewConfiguration(filepath.Join(options.LbMetricConfDir,
+				"lbclient.conf."+alias), alias))
+			tmpConfMap[alias], added = true, true
+		}
+		for _, cm := range confFiles {
+			if cm.AliasNames[0] == alias {
+				cm.Fragments = fragments
+			}
+		}
+	}
+	if added {
+		sort.SliceStable(confFiles, func(i, j int) bool {
+			return confFiles[i].ConfigFilePath < confFiles[j].ConfigFilePath
+		})
+	}
+
+	/* The files included by other configuration files are fragments, not alias configurations */
+	confFiles = withoutIncluded(confFiles, defaultMapping)
+
 	/* Abort if no configuration files were found */
 	if len(confFiles) == 0 && defaultMapping == nil {
 		return nil, fmt.Errorf("no configuration files found in the supplied directory [%s]",
@@ -94,17 +195,12 @@ func ReadLBConfigFiles(options appSettings.Options) (confFiles []*ConfigurationM
 		return nil, err
 	}
 
-	formatLbLine := regexp.MustCompile(`^\s*lbalias\s*=\s*(\S+)`)
-
 	for _, alias := range lbAliasesFileContent {
-		formatLbLine.Match([]byte(alias))
-		if !formatLbLine.Match([]byte(alias)) {
+		aliasName, ok := ParseAliasLine(alias)
+		if !ok {
 			logger.Tracef("Ignoring the line [%v]", alias)
 			continue
 		}
-
-		/* Abort if a malformed alias is found */
-		aliasName := formatLbLine.FindStringSubmatch(alias)[1]
 		logger.Tracef("Looking for alias [%s]...", aliasName)
 
 		if _, found := tmpConfMap[aliasName]; found {
@@ -122,11 +218,129 @@ func ReadLBConfigFiles(options appSettings.Options) (confFiles []*ConfigurationM
 	}
 	/* */
 	if defaultMapping != nil && len(defaultMapping.AliasNames) > 0 {
-		confFiles = append(confFiles, defaultMapping)
+		confFiles = append(confFiles, splitByAlias(defaultMapping, options.DrainConfiguration.Dir)...)
 	}
 	return confFiles, err
 }
 
+// isStructuredDefault : Tells if the given file name is the default configuration file in a structured format, e.g.
+// [lbclient.yaml] for the default [lbclient.conf]
+func isStructuredDefault(name string, options appSettings.Options) bool {
+	base := strings.TrimSuffix(options.LbMetricDefaultFileName, filepath.Ext(options.LbMetricDefaultFileName))
+	return config.IsStructured(name) && strings.TrimSuffix(name, filepath.Ext(name)) == base
+}
+
+// structuredAlias : Returns the alias of a per-alias configuration file in a structured format, e.g. [myalias.cern.ch]
+// for [lbclient.myalias.cern.ch.yaml], or an empty string if the file is not one
+func structuredAlias(name string) string {
+	if !config.IsStructured(name) || !strings.HasPrefix(name, "lbclient.") {
+		return ""
+	}
+	alias := strings.TrimPrefix(strings.TrimSuffix(name, filepath.Ext(name)), "lbclient.")
+	if !strings.HasSuffix(alias, ".cern.ch") {
+		return ""
+	}
+	return alias
+}
+
+// sameDirectory : Tells if the alias already has a configuration file in the directory of the given path
+func sameDirectory(confFiles []*ConfigurationMapping, alias, path string) bool {
+	for _, cm := range confFiles {
+		if cm.AliasNames[0] == alias && filepath.Dir(cm.ConfigFilePath) == filepath.Dir(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAliasLine : Returns the alias declared by a line of the lbaliases file ([lbalias=<name>]), and whether the line
+// declares one
+func ParseAliasLine(line string) (string, bool) {
+	match := lbAliasLine.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// withoutIncluded : Removes the configuration mappings whose file is included by another configuration file
+func withoutIncluded(confFiles []*ConfigurationMapping, defaultMapping *ConfigurationMapping) []*ConfigurationMapping {
+	all := confFiles
+	if defaultMapping != nil {
+		all = append(all[:len(all):len(all)], defaultMapping)
+	}
+	included := make(map[string]bool)
+	for _, cm := range all {
+		// The errors are reported by the evaluation
+		file, _ := config.Load(cm.ConfigFilePath, cm.Fragments...)
+		if file == nil {
+			continue
+		}
+		for _, path := range file.Includes {
+			included[path] = true
+		}
+	}
+
+	var kept []*ConfigurationMapping
+	for _, cm := range confFiles {
+		path, err := filepath.Abs(cm.ConfigFilePath)
+		if err == nil && included[path] {
+			logger.Debugf("Ignoring the file [%s], as it is included by another configuration file", cm.ConfigFilePath)
+			continue
+		}
+		kept = append(kept, cm)
+	}
+	return kept
+}
+
+// splitByAlias : Splits the default configuration mapping into one mapping per alias if its configuration depends on
+// the alias (i.e. uses the [${ALIAS}] variable or has [if alias ...] blocks), or if one of its aliases is drained by
+// its own drain file in the given directory, so that every alias gets its own metric value
+func splitByAlias(defaultMapping *ConfigurationMapping, drainDir string) []*ConfigurationMapping {
+	file, _ := config.Load(defaultMapping.ConfigFilePath, defaultMapping.Fragments...)
+	if file == nil || len(defaultMapping.AliasNames) < 2 {
+		return []*ConfigurationMapping{defaultMapping}
+	}
+	if file.References("ALIAS") {
+		logger.Debugf("The configuration file [%s] depends on the alias. Evaluating it for each alias",
+			defaultMapping.ConfigFilePath)
+	} else if drained := drainedAlias(defaultMapping.AliasNames, drainDir); len(drained) != 0 {
+		logger.Debugf("The alias [%s] of the configuration file [%s] is drained. Evaluating it for each alias",
+			drained, defaultMapping.ConfigFilePath)
+	} else {
+		return []*ConfigurationMapping{defaultMapping}
+	}
+	var split []*ConfigurationMapping
+	for _, alias := range defaultMapping.AliasNames {
+		cm := NewConfiguration(defaultMapping.ConfigFilePath, alias)
+		cm.Fragments, cm.Default = defaultMapping.Fragments, true
+		split = append(split, cm)
+	}
+	return split
+}
+
+// DrainFile : Returns the path of the drain file of the given alias in the given directory, or of the drain file of all
+// the aliases if the alias is empty
+func DrainFile(dir, alias string) string {
+	if len(alias) == 0 {
+		return filepath.Join(dir, "drain")
+	}
+	return filepath.Join(dir, "drain."+alias)
+}
+
+// drainedAlias : Returns the first of the given aliases that has its own drain file in the given directory, if any
+func drainedAlias(aliases []string, drainDir string) string {
+	if len(drainDir) == 0 {
+		return ""
+	}
+	for _, alias := range aliases {
+		if _, err := os.Stat(DrainFile(drainDir, alias)); err == nil {
+			return alias
+		}
+	}
+	return ""
+}
+
 // GetReturnCode : checks if the return code should be a string or an integer
 func GetReturnCode(appOutput bytes.Buffer, lbConfMappings []*ConfigurationMapping) (metricType, metricValue, postErmis string) {
 	if len(lbConfMappings) == 1 {
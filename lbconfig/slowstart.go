@@ -0,0 +1,109 @@
+package lbconfig
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/checks"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/filehandler"
+)
+
+// slowStart : Raises the load of the aliases that returned to service recently, so that they do not get a full share of
+// the new sessions at once. The extra load decays linearly to zero during the slow-start period, which starts at the
+// boot of the node, when the roger appstate changes to production, or when an alias is not excluded anymore. The
+// transitions are recorded in the given state
+func (l *AppLauncher) slowStart(state *State, lbConfMappings []*mapping.ConfigurationMapping, now time.Time) {
+	options := l.AppOptions.SlowStartConfiguration
+	if !options.Enabled() {
+		return
+	}
+
+	var started time.Time
+	if up, err := uptime(options.UptimeFile); err != nil {
+		logger.WithError(err).Warnf("Unable to read the uptime of the node from [%s]", options.UptimeFile)
+	} else {
+		started = now.Add(-up)
+	}
+	if appstate, err := checks.ReadRogerAppstate(options.RogerFile); err != nil {
+		logger.WithError(err).Debugf("Unable to read the roger appstate from [%s]", options.RogerFile)
+	} else if state.Roger == nil {
+		state.Roger = &WarmUp{Appstate: appstate}
+	} else if appstate != state.Roger.Appstate {
+		logger.Debugf("The roger appstate changed from [%s] to [%s]", state.Roger.Appstate, appstate)
+		state.Roger.Appstate = appstate
+		if checks.RogerInProduction(appstate) {
+			state.Roger.Started = now
+		}
+	}
+	if state.Roger != nil && state.Roger.Started.After(started) {
+		started = state.Roger.Started
+	}
+
+	for _, cm := range lbConfMappings {
+		alias, excluded := stateAlias(cm), cm.MetricValue < 0
+		state.warmed[alias] = true
+		warmUp, found := state.WarmUps[alias]
+		if !found {
+			warmUp = &WarmUp{}
+			state.WarmUps[alias] = warmUp
+		} else if warmUp.Excluded && !excluded {
+			warmUp.Started = now
+		}
+		warmUp.Excluded = excluded
+		if excluded {
+			continue
+		}
+
+		start := started
+		if warmUp.Started.After(start) {
+			start = warmUp.Started
+		}
+		remaining := options.Period - now.Sub(start)
+		if remaining <= 0 {
+			continue
+		}
+		if remaining > options.Period {
+			remaining = options.Period
+		}
+		extra := int(math.Round(float64(options.Extra) * float64(remaining) / float64(options.Period)))
+		if extra <= 0 {
+			continue
+		}
+		raiseLoad(cm, "slowstart", extra)
+		logger.Debugf("Raising the load of the aliases [%v] by [%d] for the slow-start, during another [%s]",
+			cm.AliasNames, extra, remaining.Round(time.Second))
+	}
+}
+
+// uptime : Returns the uptime of the node, from the first field of the given file (e.g. /proc/uptime)
+func uptime(path string) (time.Duration, error) {
+	line, err := filehandler.ReadFirstLineFromFile(path)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty uptime file [%s]", path)
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// raiseLoad : Adds the given extra load to a configuration mapping, and to the formula of its load, e.g.
+// [max(12, 30) + slowstart(500) = 530]
+func raiseLoad(cm *mapping.ConfigurationMapping, name string, extra int) {
+	formula := strconv.Itoa(cm.MetricValue)
+	if len(cm.Formula) != 0 {
+		formula = strings.TrimSuffix(cm.Formula, fmt.Sprintf(" = %d", cm.MetricValue))
+	}
+	cm.MetricValue += extra
+	cm.Formula = fmt.Sprintf("%s + %s(%d) = %d", formula, name, extra, cm.MetricValue)
+}
@@ -0,0 +1,292 @@
+package snmp
+
+import (
+	"crypto/rand"
+	"net"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// USM statistics reported to the managers (RFC 3414, section 5)
+var (
+	usmStatsUnsupportedSecLevels = OID{1, 3, 6, 1, 6, 3, 15, 1, 1, 1, 0}
+	usmStatsNotInTimeWindows     = OID{1, 3, 6, 1, 6, 3, 15, 1, 1, 2, 0}
+	usmStatsUnknownUserNames     = OID{1, 3, 6, 1, 6, 3, 15, 1, 1, 3, 0}
+	usmStatsUnknownEngineIDs     = OID{1, 3, 6, 1, 6, 3, 15, 1, 1, 4, 0}
+	usmStatsWrongDigests         = OID{1, 3, 6, 1, 6, 3, 15, 1, 1, 5, 0}
+)
+
+const (
+	// timeWindow : Maximum difference between the engine time of a request and the local one (RFC 3414, section 3.2)
+	timeWindow = 150
+	// maxMessageSize : Maximum size of the messages sent by the agent
+	maxMessageSize = 65507
+	// maxBulkVarBinds : Maximum amount of variable bindings in the answer to a GetBulk request
+	maxBulkVarBinds = 1000
+)
+
+// Agent : SNMP agent that answers the SNMPv2c and SNMPv3 (authNoPriv) get, getnext and getbulk requests from a
+// @see TableSource
+type Agent struct {
+	// Communities accepted in the SNMPv2c requests. SNMPv2c is disabled if there are none
+	Communities []string
+	// Users accepted in the SNMPv3 requests, by name. SNMPv3 is disabled if there are none
+	Users    map[string]*USMUser
+	EngineID string
+	Source   TableSource
+
+	engineBoots int64
+	start       time.Time
+	mutex       sync.Mutex
+	statistics  map[string]int64
+}
+
+// NewAgent : Factory-pattern function that creates and returns a new @see Agent struct instance pointer, with a
+// random engine ID
+func NewAgent(source TableSource) *Agent {
+	// Engine ID in the RFC 3411 format: enterprise number (96), followed by random octets
+	engineID := []byte{0x80, 0x00, 0x00, 0x60, 0x05, 0, 0, 0, 0, 0, 0, 0, 0}
+	_, _ = rand.Read(engineID[5:])
+	return &Agent{
+		Users:       make(map[string]*USMUser),
+		EngineID:    string(engineID),
+		Source:      source,
+		engineBoots: 1,
+		start:       time.Now(),
+		statistics:  make(map[string]int64),
+	}
+}
+
+// AddUser : Adds an SNMPv3 user from its definition. See @see ParseUSMUser
+func (a *Agent) AddUser(definition string) error {
+	user, err := ParseUSMUser(definition)
+	if err != nil {
+		return err
+	}
+	a.Users[user.Name] = user
+	return nil
+}
+
+// Serve : Answers the requests received on the given connection until it is closed
+func (a *Agent) Serve(conn net.PacketConn) error {
+	buffer := make([]byte, maxMessageSize)
+	for {
+		n, address, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return err
+		}
+		response := a.Handle(buffer[:n])
+		if response == nil {
+			continue
+		}
+		if _, err = conn.WriteTo(response, address); err != nil {
+			logger.WithError(err).Warnf("Unable to answer the SNMP request from [%s]", address)
+		}
+	}
+}
+
+// Handle : Returns the encoded answer to an encoded request, or nil if the request should be dropped
+func (a *Agent) Handle(request []byte) []byte {
+	message, err := UnmarshalMessage(request)
+	if err != nil {
+		logger.WithError(err).Debug("Dropping an SNMP message that could not be decoded")
+		return nil
+	}
+
+	var response []byte
+	switch message.Version {
+	case Version2c:
+		response, err = a.handleCommunity(message)
+	case Version3:
+		response, err = a.handleUSM(message)
+	default:
+		logger.Debugf("Dropping an SNMP message with the unsupported version [%d]", message.Version)
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Unable to answer an SNMP request")
+		return nil
+	}
+	return response
+}
+
+// handleCommunity : Answers an SNMPv2c request
+func (a *Agent) handleCommunity(message *Message) ([]byte, error) {
+	allowed := false
+	for _, community := range a.Communities {
+		allowed = allowed || community == message.Community
+	}
+	if !allowed {
+		logger.Debug("Dropping an SNMPv2c request with an unknown community")
+		return nil, nil
+	}
+
+	pdu, ok := a.process(&message.PDU)
+	if !ok {
+		return nil, nil
+	}
+	response := &Message{Version: Version2c, Community: message.Community, PDU: *pdu}
+	return response.Marshal(nil)
+}
+
+// handleUSM : Answers an SNMPv3 request, following the user-based security model checks (RFC 3414, section 3.2)
+func (a *Agent) handleUSM(message *Message) ([]byte, error) {
+	if message.SecurityModel != SecurityModelUSM {
+		logger.Debugf("Dropping an SNMPv3 request with the unsupported security model [%d]", message.SecurityModel)
+		return nil, nil
+	}
+	if message.Security.EngineID != a.EngineID {
+		// Engine ID discovery
+		return a.report(message, usmStatsUnknownEngineIDs, nil)
+	}
+	user, found := a.Users[message.Security.UserName]
+	if !found {
+		return a.report(message, usmStatsUnknownUserNames, nil)
+	}
+	if message.Flags&FlagAuth == 0 || message.Flags&FlagPriv != 0 {
+		// Only the authNoPriv security level is supported
+		return a.report(message, usmStatsUnsupportedSecLevels, nil)
+	}
+	if !message.Verify(user) {
+		return a.report(message, usmStatsWrongDigests, nil)
+	}
+	engineTime := a.engineTime()
+	if message.Security.EngineBoots != a.engineBoots ||
+		message.Security.EngineTime < engineTime-timeWindow || message.Security.EngineTime > engineTime+timeWindow {
+		return a.report(message, usmStatsNotInTimeWindows, user)
+	}
+
+	pdu, ok := a.process(&message.PDU)
+	if !ok {
+		return nil, nil
+	}
+	return a.respond(message, pdu, user)
+}
+
+// report : Answers an SNMPv3 request with a report PDU holding the given USM statistic. The report is only
+// authenticated if a user is given
+func (a *Agent) report(message *Message, statistic OID, user *USMUser) ([]byte, error) {
+	a.mutex.Lock()
+	a.statistics[statistic.String()]++
+	count := a.statistics[statistic.String()]
+	a.mutex.Unlock()
+
+	if message.Flags&FlagReportable == 0 {
+		return nil, nil
+	}
+	pdu := &PDU{
+		Type:      ReportPDU,
+		RequestID: message.PDU.RequestID,
+		VarBinds:  []VarBind{{Name: statistic, Type: BERCounter32, Value: count}},
+	}
+	return a.respond(message, pdu, user)
+}
+
+// respond : Encodes the SNMPv3 answer to a request
+func (a *Agent) respond(message *Message, pdu *PDU, user *USMUser) ([]byte, error) {
+	response := &Message{
+		Version:       Version3,
+		MsgID:         message.MsgID,
+		MaxSize:       maxMessageSize,
+		SecurityModel: SecurityModelUSM,
+		Security: USMParameters{
+			EngineID:    a.EngineID,
+			EngineBoots: a.engineBoots,
+			EngineTime:  a.engineTime(),
+		},
+		ContextEngineID: a.EngineID,
+		ContextName:     message.ContextName,
+		PDU:             *pdu,
+	}
+	if user != nil {
+		response.Flags = FlagAuth
+		response.Security.UserName = user.Name
+	}
+	return response.Marshal(user)
+}
+
+// engineTime : Returns the amount of seconds since the agent was started
+func (a *Agent) engineTime() int64 {
+	return int64(time.Since(a.start) / time.Second)
+}
+
+// process : Answers a request PDU. Returns false if the PDU type is not supported
+func (a *Agent) process(request *PDU) (*PDU, bool) {
+	response := &PDU{Type: ResponsePDU, RequestID: request.RequestID}
+	switch request.Type {
+	case GetRequestPDU:
+		table := a.Source()
+		for _, varBind := range request.VarBinds {
+			if variable := table.Get(varBind.Name); variable != nil {
+				response.VarBinds = append(response.VarBinds, snmpVarBind(variable))
+			} else {
+				response.VarBinds = append(response.VarBinds, VarBind{Name: varBind.Name, Type: BERNoSuchObject})
+			}
+		}
+	case GetNextRequestPDU:
+		table := a.Source()
+		for _, varBind := range request.VarBinds {
+			response.VarBinds = append(response.VarBinds, a.next(table, varBind.Name))
+		}
+	case GetBulkRequestPDU:
+		response.VarBinds = a.getBulk(a.Source(), request)
+	case SetRequestPDU:
+		response.VarBinds = request.VarBinds
+		response.ErrorStatus, response.ErrorIndex = ErrorNotWritable, 1
+	default:
+		logger.Debugf("Dropping an SNMP request with the unsupported PDU type [%#x]", request.Type)
+		return nil, false
+	}
+	return response, true
+}
+
+// getBulk : Answers a GetBulk request. The non-repeaters are answered as in a GetNext request, and the repeaters are
+// walked up to the maximum repetitions or the end of the MIB view
+func (a *Agent) getBulk(table *Table, request *PDU) (varBinds []VarBind) {
+	nonRepeaters := int(request.ErrorStatus)
+	if nonRepeaters < 0 {
+		nonRepeaters = 0
+	} else if nonRepeaters > len(request.VarBinds) {
+		nonRepeaters = len(request.VarBinds)
+	}
+	for _, varBind := range request.VarBinds[:nonRepeaters] {
+		varBinds = append(varBinds, a.next(table, varBind.Name))
+	}
+
+	var repeaters []OID
+	for _, varBind := range request.VarBinds[nonRepeaters:] {
+		repeaters = append(repeaters, varBind.Name)
+	}
+	for i := int64(0); i < request.ErrorIndex && len(repeaters) > 0 && len(varBinds) < maxBulkVarBinds; i++ {
+		finished := true
+		for j := range repeaters {
+			varBind := a.next(table, repeaters[j])
+			varBinds = append(varBinds, varBind)
+			if varBind.Type != BEREndOfMibView {
+				finished = false
+				repeaters[j] = varBind.Name
+			}
+		}
+		if finished {
+			break
+		}
+	}
+	return
+}
+
+// next : Returns the variable that follows the given OID, or the end of the MIB view
+func (a *Agent) next(table *Table, oid OID) VarBind {
+	if variable := table.GetNext(oid); variable != nil {
+		return snmpVarBind(variable)
+	}
+	return VarBind{Name: oid, Type: BEREndOfMibView}
+}
+
+// snmpVarBind : Converts a variable of the table into an SNMP variable binding
+func snmpVarBind(variable *Variable) VarBind {
+	if value, ok := variable.integerValue(); ok {
+		return VarBind{Name: variable.OID, Type: BERInteger, Value: value}
+	}
+	return VarBind{Name: variable.OID, Type: BEROctetString, Value: variable.Value}
+}
@@ -0,0 +1,262 @@
+package snmp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// AgentX : AgentX (RFC 2741) subagent that registers the base OID subtree with a master agent (e.g. snmpd) and
+// answers its requests from a @see TableSource
+type AgentX struct {
+	// Address of the master agent. Unix sockets are used unless it is prefixed with [tcp:]
+	Address string
+	// Timeout applied when connecting and registering with the master agent
+	Timeout time.Duration
+	// Retry is the time waited before reconnecting once the session with the master agent is lost
+	Retry time.Duration
+	Base  OID
+	// Source is called for every request, so it should answer at once (e.g. from a cached evaluation), within the
+	// timeout of the master agent
+	Source TableSource
+
+	packetID uint32
+	mutex    sync.Mutex
+	conn     net.Conn
+	stopped  bool
+}
+
+// NewAgentX : Factory-pattern function that creates and returns a new @see AgentX struct instance pointer
+func NewAgentX(address string, base OID, source TableSource) *AgentX {
+	return &AgentX{
+		Address: address,
+		Timeout: 5 * time.Second,
+		Retry:   10 * time.Second,
+		Base:    base,
+		Source:  source,
+	}
+}
+
+// Run : Keeps a session open with the master agent, reconnecting when it is lost, until the stop channel is closed
+func (a *AgentX) Run(stop <-chan struct{}) {
+	go func() {
+		<-stop
+		a.mutex.Lock()
+		a.stopped = true
+		a.mutex.Unlock()
+		a.closeConnection()
+	}()
+
+	for {
+		err := a.Serve()
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		logger.WithError(err).Warnf("The AgentX session with the master agent [%s] was lost. Retrying in [%s]...",
+			a.Address, a.Retry.String())
+		select {
+		case <-stop:
+			return
+		case <-time.After(a.Retry):
+		}
+	}
+}
+
+// Serve : Connects to the master agent, registers the base OID subtree and answers the requests until the session is
+// closed
+func (a *AgentX) Serve() error {
+	network, address := "unix", a.Address
+	if strings.HasPrefix(a.Address, "tcp:") {
+		network, address = "tcp", strings.TrimPrefix(a.Address, "tcp:")
+	}
+	conn, err := net.DialTimeout(network, address, a.Timeout)
+	if err != nil {
+		return err
+	}
+	a.mutex.Lock()
+	if a.stopped {
+		a.mutex.Unlock()
+		_ = conn.Close()
+		return fmt.Errorf("the AgentX subagent was stopped")
+	}
+	a.conn = conn
+	a.mutex.Unlock()
+	defer a.closeConnection()
+
+	contextLogger := logger.WithField("AGENTX", a.Address)
+	_ = conn.SetDeadline(time.Now().Add(a.Timeout))
+	open, err := a.exchange(conn, &AgentXPacket{
+		Type:        AgentXOpen,
+		Timeout:     byte(a.Timeout / time.Second),
+		ID:          a.Base,
+		Description: "lbclient",
+	})
+	if err != nil {
+		return fmt.Errorf("unable to open the AgentX session. Error [%s]", err.Error())
+	}
+	sessionID := open.SessionID
+	if _, err = a.exchange(conn, &AgentXPacket{
+		Type:      AgentXRegister,
+		SessionID: sessionID,
+		Priority:  127,
+		Subtree:   a.Base,
+	}); err != nil {
+		return fmt.Errorf("unable to register the OID [%s]. Error [%s]", a.Base, err.Error())
+	}
+	_ = conn.SetDeadline(time.Time{})
+	contextLogger.Infof("Registered the OID [%s] with the session [%d]", a.Base, sessionID)
+
+	for {
+		request, err := ReadAgentXPacket(conn)
+		if err != nil {
+			return err
+		}
+		contextLogger.Tracef("Received the AgentX PDU [%d] with the packet id [%d]", request.Type, request.PacketID)
+
+		response := &AgentXPacket{
+			Type:          AgentXResponse,
+			SessionID:     request.SessionID,
+			TransactionID: request.TransactionID,
+			PacketID:      request.PacketID,
+		}
+		switch request.Type {
+		case AgentXGet:
+			response.VarBinds = a.get(a.Source(), request.SearchRanges)
+		case AgentXGetNext:
+			response.VarBinds = a.getNext(a.Source(), request.SearchRanges)
+		case AgentXGetBulk:
+			response.VarBinds = a.getBulk(a.Source(), request)
+		case AgentXPing:
+			// The empty response tells the master agent that the session is alive
+		case AgentXTestSet:
+			response.Error, response.Index = AgentXNotWritable, 1
+		case AgentXCleanupSet:
+			// No response is expected
+			continue
+		case AgentXClose:
+			return fmt.Errorf("the master agent closed the session. Reason [%d]", request.Reason)
+		default:
+			response.Error = AgentXProcessingError
+		}
+
+		if err = a.send(conn, response); err != nil {
+			return err
+		}
+	}
+}
+
+// closeConnection : Closes the current connection with the master agent, if any
+func (a *AgentX) closeConnection() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.conn != nil {
+		_ = a.conn.Close()
+		a.conn = nil
+	}
+}
+
+// send : Encodes and writes a PDU
+func (a *AgentX) send(conn net.Conn, packet *AgentXPacket) error {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(data)
+	return err
+}
+
+// exchange : Sends a PDU to the master agent and waits for its successful response
+func (a *AgentX) exchange(conn net.Conn, packet *AgentXPacket) (*AgentXPacket, error) {
+	a.packetID++
+	packet.PacketID = a.packetID
+	if err := a.send(conn, packet); err != nil {
+		return nil, err
+	}
+	response, err := ReadAgentXPacket(conn)
+	if err != nil {
+		return nil, err
+	}
+	if response.Type != AgentXResponse || response.PacketID != packet.PacketID {
+		return nil, fmt.Errorf("unexpected PDU [%d] with the packet id [%d]", response.Type, response.PacketID)
+	}
+	if response.Error != AgentXNoError {
+		return nil, fmt.Errorf("the master agent returned the error [%d]", response.Error)
+	}
+	return response, nil
+}
+
+// get : Answers a Get request
+func (a *AgentX) get(table *Table, searchRanges []AgentXSearchRange) (varBinds []AgentXVarBind) {
+	for _, searchRange := range searchRanges {
+		if variable := table.Get(searchRange.Start); variable != nil {
+			varBinds = append(varBinds, agentxVarBind(variable))
+		} else {
+			varBinds = append(varBinds, AgentXVarBind{Type: AgentXNoSuchObject, Name: searchRange.Start})
+		}
+	}
+	return
+}
+
+// getNext : Answers a GetNext request
+func (a *AgentX) getNext(table *Table, searchRanges []AgentXSearchRange) (varBinds []AgentXVarBind) {
+	for _, searchRange := range searchRanges {
+		varBinds = append(varBinds, a.next(table, searchRange))
+	}
+	return
+}
+
+// getBulk : Answers a GetBulk request. The non-repeaters are answered as in a GetNext request, and the repeaters are
+// walked up to the maximum repetitions or the end of the MIB view
+func (a *AgentX) getBulk(table *Table, request *AgentXPacket) (varBinds []AgentXVarBind) {
+	nonRepeaters := int(request.NonRepeaters)
+	if nonRepeaters > len(request.SearchRanges) {
+		nonRepeaters = len(request.SearchRanges)
+	}
+	varBinds = a.getNext(table, request.SearchRanges[:nonRepeaters])
+
+	repeaters := append([]AgentXSearchRange{}, request.SearchRanges[nonRepeaters:]...)
+	for i := 0; i < int(request.MaxRepetitions) && len(repeaters) > 0; i++ {
+		finished := true
+		for j := range repeaters {
+			varBind := a.next(table, repeaters[j])
+			varBinds = append(varBinds, varBind)
+			if varBind.Type != AgentXEndOfMibView {
+				finished = false
+				repeaters[j].Start, repeaters[j].Include = varBind.Name, false
+			}
+		}
+		if finished {
+			break
+		}
+	}
+	return
+}
+
+// next : Returns the first variable within the search range, or the end of the MIB view
+func (a *AgentX) next(table *Table, searchRange AgentXSearchRange) AgentXVarBind {
+	var variable *Variable
+	if searchRange.Include {
+		variable = table.Get(searchRange.Start)
+	}
+	if variable == nil {
+		variable = table.GetNext(searchRange.Start)
+	}
+	if variable == nil || (len(searchRange.End) != 0 && variable.OID.Compare(searchRange.End) >= 0) {
+		return AgentXVarBind{Type: AgentXEndOfMibView, Name: searchRange.Start}
+	}
+	return agentxVarBind(variable)
+}
+
+// agentxVarBind : Converts a variable of the table into an AgentX variable binding
+func agentxVarBind(variable *Variable) AgentXVarBind {
+	if value, ok := variable.integerValue(); ok {
+		return AgentXVarBind{Type: AgentXInteger, Name: variable.OID, Value: value}
+	}
+	return AgentXVarBind{Type: AgentXOctetString, Name: variable.OID, Value: variable.Value}
+}
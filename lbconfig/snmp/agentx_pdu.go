@@ -0,0 +1,387 @@
+package snmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// AgentXType : AgentX PDU type (RFC 2741, section 6.1)
+type AgentXType byte
+
+// Supported AgentX PDU types
+const (
+	AgentXOpen       AgentXType = 1
+	AgentXClose      AgentXType = 2
+	AgentXRegister   AgentXType = 3
+	AgentXUnregister AgentXType = 4
+	AgentXGet        AgentXType = 5
+	AgentXGetNext    AgentXType = 6
+	AgentXGetBulk    AgentXType = 7
+	AgentXTestSet    AgentXType = 8
+	AgentXCommitSet  AgentXType = 9
+	AgentXUndoSet    AgentXType = 10
+	AgentXCleanupSet AgentXType = 11
+	AgentXPing       AgentXType = 13
+	AgentXResponse   AgentXType = 18
+)
+
+// AgentX header flags
+const (
+	agentxFlagNonDefaultContext byte = 0x08
+	agentxFlagNetworkByteOrder  byte = 0x10
+)
+
+// AgentX variable binding types (RFC 2741, section 5.4)
+const (
+	AgentXInteger          uint16 = 2
+	AgentXOctetString      uint16 = 4
+	AgentXNull             uint16 = 5
+	AgentXObjectIdentifier uint16 = 6
+	AgentXIPAddress        uint16 = 64
+	AgentXCounter32        uint16 = 65
+	AgentXGauge32          uint16 = 66
+	AgentXTimeTicks        uint16 = 67
+	AgentXOpaque           uint16 = 68
+	AgentXCounter64        uint16 = 70
+	AgentXNoSuchObject     uint16 = 128
+	AgentXNoSuchInstance   uint16 = 129
+	AgentXEndOfMibView     uint16 = 130
+)
+
+// AgentX response errors (RFC 2741, section 6.2.16)
+const (
+	AgentXNoError         uint16 = 0
+	AgentXNotWritable     uint16 = 17
+	AgentXParseError      uint16 = 266
+	AgentXProcessingError uint16 = 268
+)
+
+const agentxHeaderLength = 20
+
+// AgentXSearchRange : Range of OIDs requested by the master agent
+type AgentXSearchRange struct {
+	Start, End OID
+	Include    bool
+}
+
+// AgentXVarBind : Variable binding. The value is an [int64] for the numeric types, a [string] for the octet strings,
+// an @see OID for the object identifiers and nil for the null and exception types
+type AgentXVarBind struct {
+	Type  uint16
+	Name  OID
+	Value interface{}
+}
+
+// AgentXPacket : AgentX PDU. Only the fields relevant to the PDU type are encoded
+type AgentXPacket struct {
+	Type                               AgentXType
+	Flags                              byte
+	SessionID, TransactionID, PacketID uint32
+	Context                            string
+
+	// Open, Register
+	Timeout     byte
+	ID          OID
+	Description string
+	Priority    byte
+	Subtree     OID
+	// Close
+	Reason byte
+	// Get, GetNext, GetBulk
+	NonRepeaters, MaxRepetitions uint16
+	SearchRanges                 []AgentXSearchRange
+	// Response, TestSet
+	SysUpTime    uint32
+	Error, Index uint16
+	VarBinds     []AgentXVarBind
+}
+
+// MarshalBinary : Encodes the packet in network byte order
+func (p *AgentXPacket) MarshalBinary() ([]byte, error) {
+	order := binary.BigEndian
+	flags := p.Flags | agentxFlagNetworkByteOrder
+	payload := &bytes.Buffer{}
+
+	writeContext := func() {
+		if len(p.Context) != 0 {
+			flags |= agentxFlagNonDefaultContext
+			writeAgentXOctetString(payload, order, p.Context)
+		}
+	}
+
+	switch p.Type {
+	case AgentXOpen:
+		payload.Write([]byte{p.Timeout, 0, 0, 0})
+		writeAgentXOID(payload, order, p.ID, false)
+		writeAgentXOctetString(payload, order, p.Description)
+	case AgentXClose:
+		payload.Write([]byte{p.Reason, 0, 0, 0})
+	case AgentXRegister, AgentXUnregister:
+		writeContext()
+		payload.Write([]byte{p.Timeout, p.Priority, 0, 0})
+		writeAgentXOID(payload, order, p.Subtree, false)
+	case AgentXGet, AgentXGetNext, AgentXGetBulk:
+		writeContext()
+		if p.Type == AgentXGetBulk {
+			_ = binary.Write(payload, order, p.NonRepeaters)
+			_ = binary.Write(payload, order, p.MaxRepetitions)
+		}
+		for _, searchRange := range p.SearchRanges {
+			writeAgentXOID(payload, order, searchRange.Start, searchRange.Include)
+			writeAgentXOID(payload, order, searchRange.End, false)
+		}
+	case AgentXTestSet:
+		writeContext()
+		if err := writeAgentXVarBinds(payload, order, p.VarBinds); err != nil {
+			return nil, err
+		}
+	case AgentXPing:
+		writeContext()
+	case AgentXCommitSet, AgentXUndoSet, AgentXCleanupSet:
+	case AgentXResponse:
+		_ = binary.Write(payload, order, p.SysUpTime)
+		_ = binary.Write(payload, order, p.Error)
+		_ = binary.Write(payload, order, p.Index)
+		if err := writeAgentXVarBinds(payload, order, p.VarBinds); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("the AgentX PDU type [%d] is not supported", p.Type)
+	}
+
+	out := &bytes.Buffer{}
+	out.Write([]byte{1, byte(p.Type), flags, 0})
+	_ = binary.Write(out, order, p.SessionID)
+	_ = binary.Write(out, order, p.TransactionID)
+	_ = binary.Write(out, order, p.PacketID)
+	_ = binary.Write(out, order, uint32(payload.Len()))
+	out.Write(payload.Bytes())
+	return out.Bytes(), nil
+}
+
+// ReadAgentXPacket : Reads and decodes the next AgentX PDU from the given reader
+func ReadAgentXPacket(r io.Reader) (*AgentXPacket, error) {
+	header := make([]byte, agentxHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 1 {
+		return nil, fmt.Errorf("the AgentX version [%d] is not supported", header[0])
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if header[2]&agentxFlagNetworkByteOrder != 0 {
+		order = binary.BigEndian
+	}
+	p := &AgentXPacket{
+		Type:          AgentXType(header[1]),
+		Flags:         header[2],
+		SessionID:     order.Uint32(header[4:8]),
+		TransactionID: order.Uint32(header[8:12]),
+		PacketID:      order.Uint32(header[12:16]),
+	}
+	payload := make([]byte, order.Uint32(header[16:20]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	d := &agentxDecoder{data: payload, order: order}
+	readContext := func() {
+		if p.Flags&agentxFlagNonDefaultContext != 0 {
+			p.Context = d.octetString()
+		}
+	}
+
+	switch p.Type {
+	case AgentXOpen:
+		p.Timeout = d.bytes(4)[0]
+		p.ID, _ = d.oid()
+		p.Description = d.octetString()
+	case AgentXClose:
+		p.Reason = d.bytes(4)[0]
+	case AgentXRegister, AgentXUnregister:
+		readContext()
+		fields := d.bytes(4)
+		p.Timeout, p.Priority = fields[0], fields[1]
+		p.Subtree, _ = d.oid()
+	case AgentXGet, AgentXGetNext, AgentXGetBulk:
+		readContext()
+		if p.Type == AgentXGetBulk {
+			p.NonRepeaters = d.uint16()
+			p.MaxRepetitions = d.uint16()
+		}
+		for d.err == nil && d.pos < len(d.data) {
+			var searchRange AgentXSearchRange
+			searchRange.Start, searchRange.Include = d.oid()
+			searchRange.End, _ = d.oid()
+			p.SearchRanges = append(p.SearchRanges, searchRange)
+		}
+	case AgentXTestSet:
+		readContext()
+		p.VarBinds = d.varBinds()
+	case AgentXPing:
+		readContext()
+	case AgentXCommitSet, AgentXUndoSet, AgentXCleanupSet:
+	case AgentXResponse:
+		p.SysUpTime = d.uint32()
+		p.Error = d.uint16()
+		p.Index = d.uint16()
+		p.VarBinds = d.varBinds()
+	default:
+		return p, fmt.Errorf("the AgentX PDU type [%d] is not supported", p.Type)
+	}
+	if d.err != nil {
+		return p, fmt.Errorf("unable to decode the AgentX PDU of type [%d]. Error [%s]", p.Type, d.err.Error())
+	}
+	return p, nil
+}
+
+// writeAgentXOID : Encodes an OID, using the [1.3.6.1.x] prefix compression when possible
+func writeAgentXOID(out *bytes.Buffer, order binary.ByteOrder, oid OID, include bool) {
+	prefix := uint32(0)
+	ids := oid
+	if len(oid) >= 5 && oid[:4].Compare(OID{1, 3, 6, 1}) == 0 && oid[4] > 0 && oid[4] < 256 {
+		prefix = oid[4]
+		ids = oid[5:]
+	}
+	includeByte := byte(0)
+	if include {
+		includeByte = 1
+	}
+	out.Write([]byte{byte(len(ids)), byte(prefix), includeByte, 0})
+	for _, id := range ids {
+		_ = binary.Write(out, order, id)
+	}
+}
+
+// writeAgentXOctetString : Encodes an octet string, padded to a multiple of 4 bytes
+func writeAgentXOctetString(out *bytes.Buffer, order binary.ByteOrder, value string) {
+	_ = binary.Write(out, order, uint32(len(value)))
+	out.WriteString(value)
+	for i := len(value); i%4 != 0; i++ {
+		out.WriteByte(0)
+	}
+}
+
+// writeAgentXVarBinds : Encodes a list of variable bindings
+func writeAgentXVarBinds(out *bytes.Buffer, order binary.ByteOrder, varBinds []AgentXVarBind) error {
+	for _, varBind := range varBinds {
+		_ = binary.Write(out, order, varBind.Type)
+		_ = binary.Write(out, order, uint16(0))
+		writeAgentXOID(out, order, varBind.Name, false)
+
+		switch varBind.Type {
+		case AgentXInteger, AgentXCounter32, AgentXGauge32, AgentXTimeTicks:
+			value, ok := varBind.Value.(int64)
+			if !ok {
+				return fmt.Errorf("the value [%v] of the OID [%s] is not an integer", varBind.Value, varBind.Name)
+			}
+			_ = binary.Write(out, order, uint32(value))
+		case AgentXCounter64:
+			value, ok := varBind.Value.(int64)
+			if !ok {
+				return fmt.Errorf("the value [%v] of the OID [%s] is not an integer", varBind.Value, varBind.Name)
+			}
+			_ = binary.Write(out, order, uint64(value))
+		case AgentXOctetString, AgentXIPAddress, AgentXOpaque:
+			value, ok := varBind.Value.(string)
+			if !ok {
+				return fmt.Errorf("the value [%v] of the OID [%s] is not a string", varBind.Value, varBind.Name)
+			}
+			writeAgentXOctetString(out, order, value)
+		case AgentXObjectIdentifier:
+			value, ok := varBind.Value.(OID)
+			if !ok {
+				return fmt.Errorf("the value [%v] of the OID [%s] is not an OID", varBind.Value, varBind.Name)
+			}
+			writeAgentXOID(out, order, value, false)
+		}
+	}
+	return nil
+}
+
+// agentxDecoder : Helper struct that decodes the payload of a PDU. The first error is kept, and the following reads
+// return zero values
+type agentxDecoder struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+	err   error
+}
+
+func (d *agentxDecoder) bytes(n int) []byte {
+	if d.err != nil || d.pos+n > len(d.data) {
+		if d.err == nil {
+			d.err = fmt.Errorf("unexpected end of payload at the byte [%d]", d.pos)
+		}
+		return make([]byte, n)
+	}
+	out := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return out
+}
+
+func (d *agentxDecoder) uint16() uint16 {
+	return d.order.Uint16(d.bytes(2))
+}
+
+func (d *agentxDecoder) uint32() uint32 {
+	return d.order.Uint32(d.bytes(4))
+}
+
+func (d *agentxDecoder) uint64() uint64 {
+	return d.order.Uint64(d.bytes(8))
+}
+
+func (d *agentxDecoder) oid() (OID, bool) {
+	fields := d.bytes(4)
+	length, prefix, include := int(fields[0]), uint32(fields[1]), fields[2] != 0
+	oid := OID{}
+	if prefix != 0 {
+		oid = OID{1, 3, 6, 1, prefix}
+	}
+	for i := 0; i < length && d.err == nil; i++ {
+		oid = append(oid, d.uint32())
+	}
+	return oid, include
+}
+
+func (d *agentxDecoder) octetString() string {
+	length := int(d.uint32())
+	if length > len(d.data) {
+		d.err = fmt.Errorf("the octet string length [%d] exceeds the payload", length)
+		return ""
+	}
+	value := string(d.bytes(length))
+	if padding := length % 4; padding != 0 {
+		d.bytes(4 - padding)
+	}
+	return value
+}
+
+func (d *agentxDecoder) varBinds() (varBinds []AgentXVarBind) {
+	for d.err == nil && d.pos < len(d.data) {
+		varBind := AgentXVarBind{Type: d.uint16()}
+		d.uint16()
+		varBind.Name, _ = d.oid()
+		switch varBind.Type {
+		case AgentXInteger, AgentXCounter32, AgentXGauge32, AgentXTimeTicks:
+			value := d.uint32()
+			if varBind.Type == AgentXInteger {
+				varBind.Value = int64(int32(value))
+			} else {
+				varBind.Value = int64(value)
+			}
+		case AgentXCounter64:
+			varBind.Value = int64(d.uint64())
+		case AgentXOctetString, AgentXIPAddress, AgentXOpaque:
+			varBind.Value = d.octetString()
+		case AgentXObjectIdentifier:
+			varBind.Value, _ = d.oid()
+		}
+		varBinds = append(varBinds, varBind)
+	}
+	return
+}
@@ -0,0 +1,232 @@
+package snmp
+
+import (
+	"fmt"
+)
+
+// BER tags of the SNMP types (RFC 3416)
+const (
+	BERInteger          byte = 0x02
+	BEROctetString      byte = 0x04
+	BERNull             byte = 0x05
+	BERObjectIdentifier byte = 0x06
+	BERSequence         byte = 0x30
+	BERIPAddress        byte = 0x40
+	BERCounter32        byte = 0x41
+	BERGauge32          byte = 0x42
+	BERTimeTicks        byte = 0x43
+	BERCounter64        byte = 0x46
+	BERNoSuchObject     byte = 0x80
+	BERNoSuchInstance   byte = 0x81
+	BEREndOfMibView     byte = 0x82
+)
+
+// berTLV : Encodes a tag-length-value triplet
+func berTLV(tag byte, content []byte) []byte {
+	out := []byte{tag}
+	out = append(out, berLength(len(content))...)
+	return append(out, content...)
+}
+
+// berLength : Encodes a length in the short or long form
+func berLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	var out []byte
+	for ; length > 0; length >>= 8 {
+		out = append([]byte{byte(length)}, out...)
+	}
+	return append([]byte{0x80 | byte(len(out))}, out...)
+}
+
+// berInteger : Encodes a signed integer in its minimal two's complement form
+func berInteger(tag byte, value int64) []byte {
+	var content []byte
+	for {
+		content = append([]byte{byte(value)}, content...)
+		last := content[0]
+		value >>= 8
+		if (value == 0 && last&0x80 == 0) || (value == -1 && last&0x80 != 0) {
+			break
+		}
+	}
+	return berTLV(tag, content)
+}
+
+// berUnsigned : Encodes an unsigned integer (counters, gauges, time ticks)
+func berUnsigned(tag byte, value uint64) []byte {
+	var content []byte
+	for {
+		content = append([]byte{byte(value)}, content...)
+		value >>= 8
+		if value == 0 {
+			break
+		}
+	}
+	if content[0]&0x80 != 0 {
+		content = append([]byte{0}, content...)
+	}
+	return berTLV(tag, content)
+}
+
+// berOID : Encodes an object identifier
+func berOID(oid OID) []byte {
+	if len(oid) < 2 {
+		oid = append(oid, make(OID, 2-len(oid))...)
+	}
+	content := berBase128(40*oid[0] + oid[1])
+	for _, id := range oid[2:] {
+		content = append(content, berBase128(id)...)
+	}
+	return berTLV(BERObjectIdentifier, content)
+}
+
+// berBase128 : Encodes a sub-identifier in base 128
+func berBase128(id uint32) []byte {
+	out := []byte{byte(id & 0x7f)}
+	for id >>= 7; id > 0; id >>= 7 {
+		out = append([]byte{byte(id&0x7f) | 0x80}, out...)
+	}
+	return out
+}
+
+// berSequence : Encodes a sequence of already encoded elements
+func berSequence(tag byte, elements ...[]byte) []byte {
+	var content []byte
+	for _, element := range elements {
+		content = append(content, element...)
+	}
+	return berTLV(tag, content)
+}
+
+// berDecoder : Helper struct that decodes the elements of a BER encoded buffer. The positions are absolute, so that
+// the location of a field within the whole message can be known
+type berDecoder struct {
+	data     []byte
+	pos, end int
+}
+
+// newBERDecoder : Creates a decoder for the whole buffer
+func newBERDecoder(data []byte) *berDecoder {
+	return &berDecoder{data: data, end: len(data)}
+}
+
+// more : Checks if there are elements left
+func (d *berDecoder) more() bool {
+	return d.pos < d.end
+}
+
+// next : Decodes the next element, returning its tag and a decoder for its content
+func (d *berDecoder) next() (byte, *berDecoder, error) {
+	if d.pos+2 > d.end {
+		return 0, nil, fmt.Errorf("unexpected end of the BER element at the byte [%d]", d.pos)
+	}
+	tag := d.data[d.pos]
+	length := int(d.data[d.pos+1])
+	d.pos += 2
+	if length&0x80 != 0 {
+		size := length & 0x7f
+		if size == 0 || size > 4 || d.pos+size > d.end {
+			return 0, nil, fmt.Errorf("unsupported BER length at the byte [%d]", d.pos)
+		}
+		length = 0
+		for i := 0; i < size; i++ {
+			length = length<<8 | int(d.data[d.pos+i])
+		}
+		d.pos += size
+	}
+	if length < 0 || d.pos+length > d.end {
+		return 0, nil, fmt.Errorf("the BER length [%d] at the byte [%d] exceeds the element", length, d.pos)
+	}
+	content := &berDecoder{data: d.data, pos: d.pos, end: d.pos + length}
+	d.pos += length
+	return tag, content, nil
+}
+
+// expect : Decodes the next element, failing if it does not have the given tag
+func (d *berDecoder) expect(tag byte) (*berDecoder, error) {
+	found, content, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	if found != tag {
+		return nil, fmt.Errorf("expected the BER tag [%#x] but found [%#x]", tag, found)
+	}
+	return content, nil
+}
+
+// bytes : Returns the raw content
+func (d *berDecoder) bytes() []byte {
+	return d.data[d.pos:d.end]
+}
+
+// integer : Decodes the content as a signed integer
+func (d *berDecoder) integer() (int64, error) {
+	content := d.bytes()
+	if len(content) == 0 || len(content) > 8 {
+		return 0, fmt.Errorf("unsupported BER integer length [%d]", len(content))
+	}
+	value := int64(int8(content[0]))
+	for _, b := range content[1:] {
+		value = value<<8 | int64(b)
+	}
+	return value, nil
+}
+
+// unsigned : Decodes the content as an unsigned integer
+func (d *berDecoder) unsigned() (uint64, error) {
+	content := d.bytes()
+	if len(content) == 0 || len(content) > 9 {
+		return 0, fmt.Errorf("unsupported BER unsigned integer length [%d]", len(content))
+	}
+	value := uint64(0)
+	for _, b := range content {
+		value = value<<8 | uint64(b)
+	}
+	return value, nil
+}
+
+// oid : Decodes the content as an object identifier
+func (d *berDecoder) oid() (OID, error) {
+	content := d.bytes()
+	if len(content) == 0 {
+		return nil, fmt.Errorf("empty BER object identifier")
+	}
+	var ids []uint32
+	id := uint32(0)
+	for i, b := range content {
+		id = id<<7 | uint32(b&0x7f)
+		if b&0x80 != 0 {
+			if i == len(content)-1 {
+				return nil, fmt.Errorf("truncated BER object identifier")
+			}
+			continue
+		}
+		ids = append(ids, id)
+		id = 0
+	}
+	first := ids[0] / 40
+	if first > 2 {
+		first = 2
+	}
+	return append(OID{first, ids[0] - 40*first}, ids[1:]...), nil
+}
+
+// integerField : Decodes the next element as an INTEGER
+func (d *berDecoder) integerField() (int64, error) {
+	content, err := d.expect(BERInteger)
+	if err != nil {
+		return 0, err
+	}
+	return content.integer()
+}
+
+// stringField : Decodes the next element as an OCTET STRING
+func (d *berDecoder) stringField() (string, error) {
+	content, err := d.expect(BEROctetString)
+	if err != nil {
+		return "", err
+	}
+	return string(content.bytes()), nil
+}
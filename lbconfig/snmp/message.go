@@ -0,0 +1,328 @@
+package snmp
+
+import (
+	"crypto/hmac"
+	"fmt"
+)
+
+// PDUType : BER tag of an SNMP PDU
+type PDUType byte
+
+// Supported PDU types
+const (
+	GetRequestPDU     PDUType = 0xa0
+	GetNextRequestPDU PDUType = 0xa1
+	ResponsePDU       PDUType = 0xa2
+	SetRequestPDU     PDUType = 0xa3
+	GetBulkRequestPDU PDUType = 0xa5
+	ReportPDU         PDUType = 0xa8
+)
+
+// Supported SNMP versions
+const (
+	Version2c int64 = 1
+	Version3  int64 = 3
+)
+
+// SNMPv3 message flags
+const (
+	FlagAuth       byte = 0x01
+	FlagPriv       byte = 0x02
+	FlagReportable byte = 0x04
+)
+
+// Error statuses used in the responses
+const (
+	ErrorNoError     int64 = 0
+	ErrorNotWritable int64 = 17
+)
+
+// SecurityModelUSM : User-based security model (RFC 3414)
+const SecurityModelUSM int64 = 3
+
+// VarBind : SNMP variable binding. The value is an [int64] for the numeric types, a [string] for the octet strings,
+// an @see OID for the object identifiers and nil for the null and exception types
+type VarBind struct {
+	Name  OID
+	Type  byte
+	Value interface{}
+}
+
+// PDU : SNMP protocol data unit. In the GetBulk requests, the error status and index hold the non-repeaters and the
+// maximum repetitions
+type PDU struct {
+	Type                    PDUType
+	RequestID               int64
+	ErrorStatus, ErrorIndex int64
+	VarBinds                []VarBind
+}
+
+// USMParameters : Security parameters of the user-based security model
+type USMParameters struct {
+	EngineID                       string
+	EngineBoots, EngineTime        int64
+	UserName                       string
+	AuthParameters, PrivParameters string
+}
+
+// Message : SNMPv2c or SNMPv3 message
+type Message struct {
+	Version int64
+	// SNMPv2c
+	Community string
+	// SNMPv3
+	MsgID, MaxSize               int64
+	Flags                        byte
+	SecurityModel                int64
+	Security                     USMParameters
+	ContextEngineID, ContextName string
+
+	PDU PDU
+
+	// raw and authOffset are kept when decoding, to verify the authentication parameters
+	raw        []byte
+	authOffset int
+}
+
+// Marshal : Encodes the message. If the SNMPv3 authentication flag is set, the message is signed with the key of the
+// given user for the message engine ID
+func (m *Message) Marshal(user *USMUser) ([]byte, error) {
+	pdu, err := m.PDU.marshal()
+	if err != nil {
+		return nil, err
+	}
+	version := berInteger(BERInteger, m.Version)
+	if m.Version != Version3 {
+		return berSequence(BERSequence, version, berTLV(BEROctetString, []byte(m.Community)), pdu), nil
+	}
+
+	authParameters := []byte(m.Security.AuthParameters)
+	if m.Flags&FlagAuth != 0 {
+		if user == nil {
+			return nil, fmt.Errorf("a user is needed to authenticate the message")
+		}
+		authParameters = make([]byte, digestLength)
+	}
+	privacy := berTLV(BEROctetString, []byte(m.Security.PrivParameters))
+	security := berSequence(BERSequence,
+		berTLV(BEROctetString, []byte(m.Security.EngineID)),
+		berInteger(BERInteger, m.Security.EngineBoots),
+		berInteger(BERInteger, m.Security.EngineTime),
+		berTLV(BEROctetString, []byte(m.Security.UserName)),
+		berTLV(BEROctetString, authParameters),
+		privacy)
+	securityParameters := berTLV(BEROctetString, security)
+	header := berSequence(BERSequence,
+		berInteger(BERInteger, m.MsgID),
+		berInteger(BERInteger, m.MaxSize),
+		berTLV(BEROctetString, []byte{m.Flags}),
+		berInteger(BERInteger, m.SecurityModel))
+	scoped := berSequence(BERSequence,
+		berTLV(BEROctetString, []byte(m.ContextEngineID)),
+		berTLV(BEROctetString, []byte(m.ContextName)),
+		pdu)
+
+	out := berSequence(BERSequence, version, header, securityParameters, scoped)
+	if m.Flags&FlagAuth != 0 {
+		// The authentication parameters are the last bytes of the security parameters before the privacy ones
+		offset := len(out) - len(scoped) - len(privacy) - digestLength
+		copy(out[offset:], user.digest(m.Security.EngineID, out))
+	}
+	return out, nil
+}
+
+// Verify : Checks the authentication parameters of a decoded SNMPv3 message with the key of the given user
+func (m *Message) Verify(user *USMUser) bool {
+	if m.raw == nil || len(m.Security.AuthParameters) != digestLength {
+		return false
+	}
+	message := append([]byte{}, m.raw...)
+	copy(message[m.authOffset:], make([]byte, digestLength))
+	return hmac.Equal(user.digest(m.Security.EngineID, message), []byte(m.Security.AuthParameters))
+}
+
+// UnmarshalMessage : Decodes an SNMPv2c or SNMPv3 message. The PDU of an encrypted SNMPv3 message is not decoded
+func UnmarshalMessage(data []byte) (*Message, error) {
+	message, err := newBERDecoder(data).expect(BERSequence)
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{raw: data}
+	if m.Version, err = message.integerField(); err != nil {
+		return nil, err
+	}
+	if m.Version != Version3 {
+		if m.Community, err = message.stringField(); err != nil {
+			return nil, err
+		}
+		return m, m.PDU.unmarshal(message)
+	}
+
+	// Header data
+	header, err := message.expect(BERSequence)
+	if err != nil {
+		return nil, err
+	}
+	if m.MsgID, err = header.integerField(); err != nil {
+		return nil, err
+	}
+	if m.MaxSize, err = header.integerField(); err != nil {
+		return nil, err
+	}
+	flags, err := header.stringField()
+	if err != nil || len(flags) != 1 {
+		return nil, fmt.Errorf("invalid SNMPv3 message flags [%q]. Error [%v]", flags, err)
+	}
+	m.Flags = flags[0]
+	if m.SecurityModel, err = header.integerField(); err != nil {
+		return nil, err
+	}
+
+	// Security parameters
+	securityParameters, err := message.expect(BEROctetString)
+	if err != nil {
+		return nil, err
+	}
+	security, err := securityParameters.expect(BERSequence)
+	if err != nil {
+		return nil, err
+	}
+	if m.Security.EngineID, err = security.stringField(); err != nil {
+		return nil, err
+	}
+	if m.Security.EngineBoots, err = security.integerField(); err != nil {
+		return nil, err
+	}
+	if m.Security.EngineTime, err = security.integerField(); err != nil {
+		return nil, err
+	}
+	if m.Security.UserName, err = security.stringField(); err != nil {
+		return nil, err
+	}
+	authParameters, err := security.expect(BEROctetString)
+	if err != nil {
+		return nil, err
+	}
+	m.authOffset = authParameters.pos
+	m.Security.AuthParameters = string(authParameters.bytes())
+	if m.Security.PrivParameters, err = security.stringField(); err != nil {
+		return nil, err
+	}
+	if m.Flags&FlagPriv != 0 {
+		// Encrypted scoped PDU
+		return m, nil
+	}
+
+	// Scoped PDU
+	scoped, err := message.expect(BERSequence)
+	if err != nil {
+		return nil, err
+	}
+	if m.ContextEngineID, err = scoped.stringField(); err != nil {
+		return nil, err
+	}
+	if m.ContextName, err = scoped.stringField(); err != nil {
+		return nil, err
+	}
+	return m, m.PDU.unmarshal(scoped)
+}
+
+// marshal : Encodes the PDU
+func (p *PDU) marshal() ([]byte, error) {
+	var varBinds [][]byte
+	for _, varBind := range p.VarBinds {
+		value, err := varBind.marshalValue()
+		if err != nil {
+			return nil, err
+		}
+		varBinds = append(varBinds, berSequence(BERSequence, berOID(varBind.Name), value))
+	}
+	return berSequence(byte(p.Type),
+		berInteger(BERInteger, p.RequestID),
+		berInteger(BERInteger, p.ErrorStatus),
+		berInteger(BERInteger, p.ErrorIndex),
+		berSequence(BERSequence, varBinds...)), nil
+}
+
+// unmarshal : Decodes the next element of the decoder as a PDU
+func (p *PDU) unmarshal(d *berDecoder) error {
+	tag, pdu, err := d.next()
+	if err != nil {
+		return err
+	}
+	p.Type = PDUType(tag)
+	if p.RequestID, err = pdu.integerField(); err != nil {
+		return err
+	}
+	if p.ErrorStatus, err = pdu.integerField(); err != nil {
+		return err
+	}
+	if p.ErrorIndex, err = pdu.integerField(); err != nil {
+		return err
+	}
+	varBinds, err := pdu.expect(BERSequence)
+	if err != nil {
+		return err
+	}
+	for varBinds.more() {
+		element, err := varBinds.expect(BERSequence)
+		if err != nil {
+			return err
+		}
+		name, err := element.expect(BERObjectIdentifier)
+		if err != nil {
+			return err
+		}
+		var varBind VarBind
+		if varBind.Name, err = name.oid(); err != nil {
+			return err
+		}
+		tag, value, err := element.next()
+		if err != nil {
+			return err
+		}
+		varBind.Type = tag
+		switch tag {
+		case BERInteger:
+			varBind.Value, err = value.integer()
+		case BERCounter32, BERGauge32, BERTimeTicks, BERCounter64:
+			var unsigned uint64
+			unsigned, err = value.unsigned()
+			varBind.Value = int64(unsigned)
+		case BEROctetString, BERIPAddress:
+			varBind.Value = string(value.bytes())
+		case BERObjectIdentifier:
+			varBind.Value, err = value.oid()
+		}
+		if err != nil {
+			return err
+		}
+		p.VarBinds = append(p.VarBinds, varBind)
+	}
+	return nil
+}
+
+// marshalValue : Encodes the value of the variable binding
+func (v *VarBind) marshalValue() ([]byte, error) {
+	switch v.Type {
+	case BERInteger:
+		if value, ok := v.Value.(int64); ok {
+			return berInteger(BERInteger, value), nil
+		}
+	case BERCounter32, BERGauge32, BERTimeTicks, BERCounter64:
+		if value, ok := v.Value.(int64); ok {
+			return berUnsigned(v.Type, uint64(value)), nil
+		}
+	case BEROctetString, BERIPAddress:
+		if value, ok := v.Value.(string); ok {
+			return berTLV(v.Type, []byte(value)), nil
+		}
+	case BERObjectIdentifier:
+		if value, ok := v.Value.(OID); ok {
+			return berOID(value), nil
+		}
+	case BERNull, BERNoSuchObject, BERNoSuchInstance, BEREndOfMibView:
+		return berTLV(v.Type, nil), nil
+	}
+	return nil, fmt.Errorf("the value [%v] of the OID [%s] does not match the type [%#x]", v.Value, v.Name, v.Type)
+}
@@ -0,0 +1,69 @@
+package snmp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OID : SNMP object identifier, stored as the list of its sub-identifiers
+type OID []uint32
+
+// ParseOID : Parses an OID in the dotted format (e.g. [.1.3.6.1.4.1.96.255.1]). The leading dot is optional
+func ParseOID(raw string) (OID, error) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), ".")
+	if len(raw) == 0 {
+		return OID{}, nil
+	}
+
+	parts := strings.Split(raw, ".")
+	oid := make(OID, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("the OID [%s] is not valid. Error [%s]", raw, err.Error())
+		}
+		oid[i] = uint32(value)
+	}
+	return oid, nil
+}
+
+// String : Returns the OID in the dotted format, with a leading dot
+func (oid OID) String() string {
+	var out strings.Builder
+	for _, id := range oid {
+		out.WriteString(".")
+		out.WriteString(strconv.FormatUint(uint64(id), 10))
+	}
+	return out.String()
+}
+
+// Compare : Compares the OID lexicographically with another one. Returns -1, 0 or 1 if the OID is respectively
+// lower, equal or greater than the other one
+func (oid OID) Compare(other OID) int {
+	for i := 0; i < len(oid) && i < len(other); i++ {
+		if oid[i] < other[i] {
+			return -1
+		} else if oid[i] > other[i] {
+			return 1
+		}
+	}
+	if len(oid) < len(other) {
+		return -1
+	} else if len(oid) > len(other) {
+		return 1
+	}
+	return 0
+}
+
+// HasPrefix : Checks if the OID is the given prefix or one of its descendants
+func (oid OID) HasPrefix(prefix OID) bool {
+	return len(oid) >= len(prefix) && oid[:len(prefix)].Compare(prefix) == 0
+}
+
+// Append : Returns a new OID with the given sub-identifiers appended
+func (oid OID) Append(ids ...uint32) OID {
+	out := make(OID, 0, len(oid)+len(ids))
+	out = append(out, oid...)
+	return append(out, ids...)
+}
@@ -0,0 +1,84 @@
+package snmp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// TableSource : Function that returns the table of variables to be used when answering a request
+type TableSource func() *Table
+
+// ServePassPersist : Speaks the net-snmp pass_persist protocol over the given reader and writer until the reader is
+// closed or an empty command is received. See the [pass_persist] section of snmpd.conf(5)
+func ServePassPersist(in io.Reader, out io.Writer, source TableSource) error {
+	scanner := bufio.NewScanner(in)
+	// readLine : returns the next line, or false if the reader was closed
+	readLine := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		return strings.TrimSpace(scanner.Text()), true
+	}
+
+	for {
+		command, ok := readLine()
+		if !ok || len(command) == 0 {
+			return scanner.Err()
+		}
+
+		contextLogger := logger.WithField("COMMAND", command)
+		var answer string
+		switch strings.ToLower(command) {
+		case "ping":
+			answer = "PONG\n"
+		case "get", "getnext":
+			rawOID, ok := readLine()
+			if !ok {
+				return scanner.Err()
+			}
+			contextLogger.Tracef("Received a request for the OID [%s]", rawOID)
+			answer = PassAnswer(source(), command, rawOID)
+		case "set":
+			// Read the OID and the [type value] lines, nothing is writable
+			if _, ok := readLine(); !ok {
+				return scanner.Err()
+			}
+			if _, ok := readLine(); !ok {
+				return scanner.Err()
+			}
+			answer = "not-writable\n"
+		default:
+			contextLogger.Warn("Ignoring an unsupported pass_persist command")
+			answer = "NONE\n"
+		}
+
+		if _, err := io.WriteString(out, answer); err != nil {
+			return err
+		}
+	}
+}
+
+// PassAnswer : Returns the answer to a [get] or [getnext] request in the format of the net-snmp pass protocol, i.e.
+// the OID, type and value on three lines, or [NONE] if there is no such variable
+func PassAnswer(table *Table, command, rawOID string) string {
+	oid, err := ParseOID(rawOID)
+	if err != nil {
+		logger.WithError(err).Warn("Unable to parse the requested OID")
+		return "NONE\n"
+	}
+
+	var variable *Variable
+	if strings.ToLower(command) == "getnext" {
+		variable = table.GetNext(oid)
+	} else {
+		variable = table.Get(oid)
+	}
+	if variable == nil {
+		return "NONE\n"
+	}
+	return fmt.Sprintf("%s\n%s\n%s\n", variable.OID.String(), variable.Type, variable.Value)
+}
@@ -0,0 +1,87 @@
+package snmp
+
+import (
+	"sort"
+	"strconv"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// Value types, as named by the net-snmp pass and pass_persist protocols
+const (
+	TypeInteger = "integer"
+	TypeString  = "string"
+)
+
+// Variable : SNMP variable binding served by lbclient
+type Variable struct {
+	OID   OID
+	Type  string
+	Value string
+}
+
+// integerValue : Returns the value of an integer variable, or false if the variable is not an integer
+func (v *Variable) integerValue() (int64, bool) {
+	if v.Type != TypeInteger {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(v.Value, 10, 32)
+	return value, err == nil
+}
+
+// Table : Ordered list of the variables served by lbclient
+type Table struct {
+	variables []Variable
+}
+
+// NewTable : Creates the table of variables for the given evaluation output. The base OID holds the application
+// output (@see mapping.GetReturnCode), and each alias gets its own integer sub-OID ([base].1, [base].2, ...) following
+// the order of the configuration mappings
+func NewTable(base OID, metricType, metricValue string, mappings []*mapping.ConfigurationMapping) *Table {
+	table := &Table{}
+	if len(metricValue) == 0 {
+		return table
+	}
+	table.variables = append(table.variables, Variable{OID: base, Type: metricType, Value: metricValue})
+
+	index := uint32(0)
+	for _, cm := range mappings {
+		for range cm.AliasNames {
+			index++
+			table.variables = append(table.variables, Variable{
+				OID:   base.Append(index),
+				Type:  TypeInteger,
+				Value: strconv.Itoa(cm.MetricValue),
+			})
+		}
+	}
+	sort.Slice(table.variables, func(i, j int) bool {
+		return table.variables[i].OID.Compare(table.variables[j].OID) < 0
+	})
+	return table
+}
+
+// Get : Returns the variable with the given OID, or nil if there is none
+func (t *Table) Get(oid OID) *Variable {
+	for i := range t.variables {
+		if t.variables[i].OID.Compare(oid) == 0 {
+			return &t.variables[i]
+		}
+	}
+	return nil
+}
+
+// GetNext : Returns the first variable whose OID follows the given one, or nil if there is none
+func (t *Table) GetNext(oid OID) *Variable {
+	for i := range t.variables {
+		if t.variables[i].OID.Compare(oid) > 0 {
+			return &t.variables[i]
+		}
+	}
+	return nil
+}
+
+// Len : Returns the amount of variables in the table
+func (t *Table) Len() int {
+	return len(t.variables)
+}
@@ -0,0 +1,91 @@
+package snmp
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// digestLength : Length of the HMAC-MD5-96 and HMAC-SHA-96 authentication parameters (RFC 3414)
+const digestLength = 12
+
+// USMUser : SNMPv3 user of the user-based security model, authenticated with HMAC-MD5-96 or HMAC-SHA-96
+type USMUser struct {
+	Name     string
+	Protocol string
+	Password string
+
+	mutex sync.Mutex
+	// keys caches the localized keys for each engine ID, since their derivation is expensive
+	keys map[string][]byte
+}
+
+// ParseUSMUser : Parses a user definition in the format [<name>:<MD5|SHA>:<password>]
+func ParseUSMUser(definition string) (*USMUser, error) {
+	parts := strings.SplitN(definition, ":", 3)
+	if len(parts) != 3 || len(parts[0]) == 0 {
+		return nil, fmt.Errorf("the SNMPv3 user [%s] does not follow the format [<name>:<MD5|SHA>:<password>]",
+			parts[0])
+	}
+	user := &USMUser{Name: parts[0], Protocol: strings.ToUpper(parts[1]), Password: parts[2]}
+	if user.Protocol != "MD5" && user.Protocol != "SHA" {
+		return nil, fmt.Errorf("the authentication protocol [%s] of the SNMPv3 user [%s] is not supported",
+			parts[1], user.Name)
+	}
+	if len(user.Password) < 8 {
+		return nil, fmt.Errorf("the password of the SNMPv3 user [%s] needs at least 8 characters", user.Name)
+	}
+	return user, nil
+}
+
+// newHash : Returns the hash function of the authentication protocol
+func (u *USMUser) newHash() hash.Hash {
+	if u.Protocol == "MD5" {
+		return md5.New()
+	}
+	return sha1.New()
+}
+
+// localizedKey : Derives the authentication key of the user for the given engine ID (RFC 3414, appendix A.2)
+func (u *USMUser) localizedKey(engineID string) []byte {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if key, found := u.keys[engineID]; found {
+		return key
+	}
+
+	h := u.newHash()
+	password := []byte(u.Password)
+	block := make([]byte, 64)
+	for count, index := 0, 0; count < 1048576; count += len(block) {
+		for i := range block {
+			block[i] = password[index%len(password)]
+			index++
+		}
+		h.Write(block)
+	}
+	passwordKey := h.Sum(nil)
+
+	h = u.newHash()
+	h.Write(passwordKey)
+	h.Write([]byte(engineID))
+	h.Write(passwordKey)
+	key := h.Sum(nil)
+
+	if u.keys == nil {
+		u.keys = make(map[string][]byte)
+	}
+	u.keys[engineID] = key
+	return key
+}
+
+// digest : Returns the authentication parameters of the whole message
+func (u *USMUser) digest(engineID string, message []byte) []byte {
+	mac := hmac.New(u.newHash, u.localizedKey(engineID))
+	mac.Write(message)
+	return mac.Sum(nil)[:digestLength]
+}
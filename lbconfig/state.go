@@ -0,0 +1,328 @@
+package lbconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// CheckState : Recent results of a check line of an alias, kept between the evaluations to dampen the flapping checks.
+// Failures and Successes count the consecutive results, and Failing tells if the check is considered as failing
+type CheckState struct {
+	Failures  int       `json:"failures"`
+	Successes int       `json:"successes"`
+	Failing   bool      `json:"failing"`
+	Updated   time.Time `json:"updated"`
+}
+
+// WarmUp : Last transition of an alias (or of the roger appstate of the node) returning to service, which starts its
+// slow-start period
+type WarmUp struct {
+	Excluded bool      `json:"excluded,omitempty"`
+	Appstate string    `json:"appstate,omitempty"`
+	Started  time.Time `json:"started"`
+}
+
+// State : Recent results of the check lines (and of the soft checks), indexed by alias and by line, and the transitions
+// starting the slow-start periods
+type State struct {
+	Aliases map[string]map[string]*CheckState `json:"aliases"`
+	WarmUps map[string]*WarmUp                `json:"warmups,omitempty"`
+	Roger   *WarmUp                           `json:"roger,omitempty"`
+	// checked and warmed are the lines and the aliases seen by the current evaluation, the others are dropped
+	checked map[string]map[string]bool
+	warmed  map[string]bool
+}
+
+// NewState : Creates an empty state
+func NewState() *State {
+	return &State{Aliases: make(map[string]map[string]*CheckState), WarmUps: make(map[string]*WarmUp),
+		checked: make(map[string]map[string]bool), warmed: make(map[string]bool)}
+}
+
+// ReadState : Reads a state file. A missing file is an empty state
+func ReadState(path string) (*State, error) {
+	state := NewState()
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("invalid state file [%s]: %v", path, err)
+	}
+	if state.Aliases == nil {
+		state.Aliases = make(map[string]map[string]*CheckState)
+	}
+	if state.WarmUps == nil {
+		state.WarmUps = make(map[string]*WarmUp)
+	}
+	return state, nil
+}
+
+// Write : Writes the state file, creating its directory if needed. The file is replaced at once, so that a concurrent
+// reader never sees half of it
+func (s *State) Write(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockState : Takes an exclusive advisory lock on the state file, through the [<file>.lock] file next to it, so that
+// the concurrent runs (e.g. a periodic evaluation and the [state] command) do not lose the updates of each other.
+// Returns the function releasing the lock
+func lockState(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+// prune : Drops the lines and the aliases that the current evaluation did not see, e.g. once a line is removed from a
+// configuration file or an alias from the node
+func (s *State) prune() {
+	for alias, checks := range s.Aliases {
+		for key := range checks {
+			if !s.checked[alias][key] {
+				delete(checks, key)
+			}
+		}
+		if len(checks) == 0 {
+			delete(s.Aliases, alias)
+		}
+	}
+	for alias := range s.WarmUps {
+		if !s.warmed[alias] {
+			delete(s.WarmUps, alias)
+		}
+	}
+}
+
+// observe : Records a result of the check, and returns whether the check is considered as failing: it starts failing
+// after the given amount of consecutive failures, and stops after the given amount of consecutive successes
+func (c *CheckState) observe(failed bool, failures, successes int, now time.Time) bool {
+	if failed {
+		c.Failures, c.Successes = c.Failures+1, 0
+	} else {
+		c.Failures, c.Successes = 0, c.Successes+1
+	}
+	if !c.Failing && c.Failures >= failures {
+		c.Failing = true
+	} else if c.Failing && c.Successes >= successes {
+		c.Failing = false
+	}
+	c.Updated = now
+	return c.Failing
+}
+
+// dampener : Applies the dampening rules to the results of the checks, with the state of the previous evaluations. It
+// is shared by the concurrent evaluations of a run
+type dampener struct {
+	mutex     sync.Mutex
+	state     *State
+	failures  int
+	successes int
+}
+
+// dampen : Records the result of a check line of the given configuration mapping, whose arguments are expanded, and
+// returns whether the check should be considered as failing
+func (d *dampener) dampen(cm *mapping.ConfigurationMapping, action *config.Action, failed bool) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	alias := stateAlias(cm)
+	checks, found := d.state.Aliases[alias]
+	if !found {
+		checks = make(map[string]*CheckState)
+		d.state.Aliases[alias] = checks
+	}
+	key := strings.Join(strings.Fields(action.Kind+" "+strings.ToLower(action.Keyword)+" "+action.Args), " ")
+	check, found := checks[key]
+	if !found {
+		check = &CheckState{}
+		checks[key] = check
+	}
+	if d.state.checked[alias] == nil {
+		d.state.checked[alias] = make(map[string]bool)
+	}
+	d.state.checked[alias][key] = true
+	return check.observe(failed, d.failures, d.successes, time.Now())
+}
+
+// stateAlias : Returns the key of the state of a configuration mapping: its aliases, or [default] if it has none
+func stateAlias(cm *mapping.ConfigurationMapping) string {
+	if len(cm.AliasNames) == 0 {
+		return "[default]"
+	}
+	return strings.Join(cm.AliasNames, ",")
+}
+
+// selectAlias : Returns the part of the state whose key is the given alias, or a list of aliases including it
+func (s *State) selectAlias(alias string) *State {
+	matches := func(key string) bool {
+		for _, name := range strings.Split(key, ",") {
+			if name == alias {
+				return true
+			}
+		}
+		return false
+	}
+	selected := NewState()
+	for key, checks := range s.Aliases {
+		if matches(key) {
+			selected.Aliases[key] = checks
+		}
+	}
+	for key, warmUp := range s.WarmUps {
+		if matches(key) {
+			selected.WarmUps[key] = warmUp
+		}
+	}
+	return selected
+}
+
+// readState : Locks and reads the state file, if the dampening of the checks or the slow-start is enabled, and returns
+// the function releasing the lock once the state is written. A state file that cannot be read is replaced by an empty
+// state
+func (l *AppLauncher) readState() (*State, func()) {
+	options := l.AppOptions.StateConfiguration
+	if !options.Dampening() && !l.AppOptions.SlowStartConfiguration.Enabled() {
+		return nil, func() {}
+	}
+	unlock, err := lockState(options.File)
+	if err != nil {
+		logger.WithError(err).Warnf("Unable to lock the state file [%s]", options.File)
+		unlock = func() {}
+	}
+	state, err := ReadState(options.File)
+	if err != nil {
+		logger.WithError(err).Warnf("Unable to read the state file [%s]. Starting with an empty state", options.File)
+		state = NewState()
+	}
+	return state, unlock
+}
+
+// newDampener : Creates the dampener of the checks with the given state, if the dampening is enabled
+func (l *AppLauncher) newDampener(state *State) *dampener {
+	options := l.AppOptions.StateConfiguration
+	if state == nil || !options.Dampening() {
+		return nil
+	}
+	return &dampener{state: state, failures: options.Failures, successes: options.Successes}
+}
+
+// State : Writes the state of the checks kept by the dampening (only the one of the requested alias, if any) to the
+// given writer, in the requested format, or resets it. The state of the aliases sharing the default configuration file
+// is kept under all of them (e.g. [a.cern.ch,b.cern.ch]), and is selected by any of them
+func (l *AppLauncher) State(out io.Writer) error {
+	options, path := l.AppOptions.State, l.AppOptions.StateConfiguration.File
+	if options.Reset {
+		if _, err := os.Stat(path); os.IsNotExist(err) && len(options.Alias) == 0 {
+			return nil
+		}
+		unlock, err := lockState(path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	state, err := ReadState(path)
+	if err != nil {
+		return err
+	}
+	if options.Reset && len(options.Alias) == 0 {
+		return NewState().Write(path)
+	}
+
+	if len(options.Alias) != 0 {
+		selected := state.selectAlias(options.Alias)
+		if len(selected.Aliases) == 0 && len(selected.WarmUps) == 0 {
+			return fmt.Errorf("no state found for the alias [%s] in [%s]", options.Alias, path)
+		}
+		if options.Reset {
+			for key := range selected.Aliases {
+				delete(state.Aliases, key)
+			}
+			for key := range selected.WarmUps {
+				delete(state.WarmUps, key)
+			}
+			return state.Write(path)
+		}
+		state = selected
+	}
+	if options.Format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(state)
+	}
+
+	var aliases []string
+	for alias := range state.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "ALIAS\tCHECK\tFAILURES\tSUCCESSES\tSTATUS\tUPDATED")
+	for _, alias := range aliases {
+		var lines []string
+		for line := range state.Aliases[alias] {
+			lines = append(lines, line)
+		}
+		sort.Strings(lines)
+		for _, line := range lines {
+			check := state.Aliases[alias][line]
+			status := "ok"
+			if check.Failing {
+				status = "FAILING"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%s\t%s\n", alias, line, check.Failures, check.Successes, status,
+				check.Updated.Format(time.RFC3339))
+		}
+	}
+	return table.Flush()
+}
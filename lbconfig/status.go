@@ -0,0 +1,221 @@
+package lbconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/metrics"
+)
+
+// LineStatus : JSON representation of the result of an action (check, soft check or load) line. File is the
+// configuration file or the fragment of the line
+type LineStatus struct {
+	File     string `json:"file"`
+	Number   int    `json:"number"`
+	Line     string `json:"line"`
+	Action   string `json:"action"`
+	Kind     string `json:"kind"`
+	Result   int    `json:"result"`
+	Total    int    `json:"total"`
+	Excluded bool   `json:"excluded,omitempty"`
+	Cached   bool   `json:"cached,omitempty"`
+	Penalty  int    `json:"penalty,omitempty"`
+	Dampened bool   `json:"dampened,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AliasStatus : JSON representation of the last evaluation of an alias
+type AliasStatus struct {
+	Alias       string       `json:"alias"`
+	ConfigFile  string       `json:"config_file"`
+	Fragments   []string     `json:"fragments,omitempty"`
+	MetricValue int          `json:"metric_value"`
+	FailureCode int          `json:"failure_code"`
+	Duration    string       `json:"duration"`
+	Error       string       `json:"error,omitempty"`
+	Lines       []LineStatus `json:"lines"`
+}
+
+// Status : JSON representation of a @see Snapshot
+type Status struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	Age         string        `json:"age"`
+	Duration    string        `json:"duration"`
+	MetricType  string        `json:"metric_type"`
+	MetricValue string        `json:"metric_value"`
+	Error       string        `json:"error,omitempty"`
+	Aliases     []AliasStatus `json:"aliases"`
+}
+
+// NewStatus : Creates the JSON representation of the given snapshot
+func NewStatus(snapshot *Snapshot) *Status {
+	status := &Status{
+		Timestamp:   snapshot.Timestamp,
+		Age:         snapshot.Age().String(),
+		Duration:    snapshot.Duration.String(),
+		MetricType:  snapshot.MetricType,
+		MetricValue: snapshot.MetricValue,
+		Error:       errorString(snapshot.Err),
+		Aliases:     []AliasStatus{},
+	}
+	for _, cm := range snapshot.Mappings {
+		lines := []LineStatus{}
+		for _, result := range cm.Results {
+			lines = append(lines, newLineStatus(result))
+		}
+		for _, alias := range cm.AliasNames {
+			status.Aliases = append(status.Aliases, AliasStatus{
+				Alias:       alias,
+				ConfigFile:  cm.ConfigFilePath,
+				Fragments:   cm.Fragments,
+				MetricValue: cm.MetricValue,
+				FailureCode: cm.FailureCode,
+				Duration:    cm.Duration.String(),
+				Error:       errorString(cm.Err),
+				Lines:       lines,
+			})
+		}
+	}
+	return status
+}
+
+// newLineStatus : Creates the JSON representation of the result of an action line
+func newLineStatus(result mapping.LineResult) LineStatus {
+	kind := "check"
+	if result.IsLoad {
+		kind = "load"
+	} else if result.IsPenalty {
+		kind = "penalize"
+	} else if result.Action == "" {
+		kind = "invalid"
+	}
+	return LineStatus{
+		File:     result.File,
+		Number:   result.Number,
+		Line:     result.Line,
+		Action:   result.Action,
+		Kind:     kind,
+		Result:   result.Value,
+		Total:    result.Total,
+		Excluded: result.Excluded,
+		Cached:   result.Cached,
+		Penalty:  result.Penalty,
+		Dampened: result.Dampened,
+		Error:    errorString(result.Err),
+	}
+}
+
+// errorString : Returns the message of the error, or an empty string if there is none
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// NewStatusHandler : Creates the HTTP handler that serves the cached snapshot of the given daemon as JSON, and the
+// Prometheus metrics. The configuration files are never evaluated by the handler itself:
+//
+//	/status         - all the aliases
+//	/status/<alias> - a single alias
+//	/metrics        - the Prometheus metrics, in the text exposition format
+func NewStatusHandler(daemon *Daemon) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := metrics.Default.WriteText(w); err != nil {
+			logger.WithError(err).Warn("Unable to answer the metrics request")
+		}
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if status := cachedStatus(w, daemon); status != nil {
+			writeJSON(w, http.StatusOK, status)
+		}
+	})
+	mux.HandleFunc("/status/", func(w http.ResponseWriter, r *http.Request) {
+		status := cachedStatus(w, daemon)
+		if status == nil {
+			return
+		}
+		alias := strings.TrimPrefix(r.URL.Path, "/status/")
+		for _, aliasStatus := range status.Aliases {
+			if aliasStatus.Alias == alias {
+				writeJSON(w, http.StatusOK, aliasStatus)
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown alias [" + alias + "]"})
+	})
+	return mux
+}
+
+// cachedStatus : Returns the status of the cached snapshot. If there is none yet, the request is answered with an
+// error and nil is returned
+func cachedStatus(w http.ResponseWriter, daemon *Daemon) *Status {
+	snapshot := daemon.Snapshot()
+	if snapshot == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "no evaluation finished yet"})
+		return nil
+	}
+	return NewStatus(snapshot)
+}
+
+// writeJSON : Answers an HTTP request with the given value encoded as JSON
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		logger.WithError(err).Warn("Unable to answer the status request")
+	}
+}
+
+// ListenStatus : Opens the listener of the status API. The address is either [<host>:<port>] on the loopback interface
+// or [unix:<path>], since the status shows the command lines and the contents of the configuration files. A stale
+// unix socket file is replaced
+func ListenStatus(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, "unix:") {
+		if err := checkLoopback(address); err != nil {
+			return nil, err
+		}
+		return net.Listen("tcp", address)
+	}
+	path := strings.TrimPrefix(address, "unix:")
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// checkLoopback : Checks that the host of the given TCP address only resolves to loopback addresses
+func checkLoopback(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if len(host) == 0 {
+		return fmt.Errorf("the status API cannot listen on all the interfaces [%s]. Use a loopback address (e.g. "+
+			"[127.0.0.1:%s]) or a unix socket", address, strings.TrimPrefix(address, ":"))
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = net.LookupIP(host); err != nil {
+			return err
+		}
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() {
+			return fmt.Errorf("the status API can only listen on the loopback interface, but [%s] resolves to [%s]. "+
+				"Use a loopback address or a unix socket", address, ip)
+		}
+	}
+	return nil
+}
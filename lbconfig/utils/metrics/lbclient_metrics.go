@@ -0,0 +1,23 @@
+package metrics
+
+// Metrics exported by lbclient
+var (
+	/* Evaluation */
+	EvaluationTimestamp = Default.NewGauge("lbclient_evaluation_timestamp_seconds",
+		"Unix time of the last evaluation of the configuration files")
+	EvaluationDuration = Default.NewGauge("lbclient_evaluation_duration_seconds",
+		"Duration of the last evaluation of the configuration files")
+	AliasLoad = Default.NewGauge("lbclient_alias_load",
+		"Metric value of the alias in the last evaluation. Negative values exclude the node from the alias", "alias")
+	CheckPassed = Default.NewGauge("lbclient_check_passed",
+		"Whether the check or load line passed (1) or failed (0) in the last evaluation", "alias", "check", "line")
+	CheckDuration = Default.NewHistogram("lbclient_check_duration_seconds",
+		"Duration of the check and load lines that ran, excluding the reused results", DefaultBuckets, "check")
+	CheckTimeouts = Default.NewCounter("lbclient_check_timeouts_total",
+		"Number of check and load lines that reached the timeout value", "check")
+	/* External commands */
+	CommandDuration = Default.NewHistogram("lbclient_command_duration_seconds",
+		"Duration of the external commands", DefaultBuckets, "command")
+	CommandFailures = Default.NewCounter("lbclient_command_failures_total",
+		"Number of external commands that failed or exited with a non-zero status", "command")
+)
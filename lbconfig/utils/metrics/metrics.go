@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets : Upper bounds (in seconds) of the histogram buckets. They reach the default metric timeout (30s)
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Registry : Collection of metric families that can be exported in the Prometheus text format
+type Registry struct {
+	mutex    sync.Mutex
+	families []*family
+}
+
+// NewRegistry : Factory-pattern function that creates and returns a new @see Registry struct instance pointer
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default : Registry holding all the metrics of lbclient
+var Default = NewRegistry()
+
+// family : Metric with a name, a type and one series per combination of label values
+type family struct {
+	registry   *Registry
+	name, help string
+	kind       string
+	labels     []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+// series : Values of a metric for a combination of label values. Gauges and counters only use the value, while
+// histograms also use the bucket counts
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+}
+
+// Gauge : Metric whose value can go up and down
+type Gauge struct{ *family }
+
+// Counter : Metric whose value only goes up
+type Counter struct{ *family }
+
+// Histogram : Metric that counts the observations in buckets
+type Histogram struct{ *family }
+
+// NewGauge : Registers a new gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, "gauge", labels, nil)}
+}
+
+// NewCounter : Registers a new counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, "counter", labels, nil)}
+}
+
+// NewHistogram : Registers a new histogram with the given bucket upper bounds and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r.register(name, help, "histogram", labels, buckets)}
+}
+
+// register : Adds a new metric family to the registry
+func (r *Registry) register(name, help, kind string, labels []string, buckets []float64) *family {
+	f := &family{
+		registry: r,
+		name:     name,
+		help:     help,
+		kind:     kind,
+		labels:   labels,
+		buckets:  buckets,
+		series:   make(map[string]*series),
+	}
+	r.mutex.Lock()
+	r.families = append(r.families, f)
+	r.mutex.Unlock()
+	return f
+}
+
+// get : Returns the series for the given label values, creating it if needed. The caller needs to hold the registry
+// mutex
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("the metric [%s] expects the labels %v but got the values %v", f.name, f.labels, labelValues))
+	}
+	key := strings.Join(labelValues, "\x00")
+	s, found := f.series[key]
+	if !found {
+		s = &series{labelValues: append([]string{}, labelValues...), counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Reset : Removes all the series of the metric (e.g. the ones of the aliases that are no longer configured)
+func (f *family) Reset() {
+	f.registry.mutex.Lock()
+	defer f.registry.mutex.Unlock()
+	f.series = make(map[string]*series)
+}
+
+// Set : Sets the value of the gauge for the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.registry.mutex.Lock()
+	defer g.registry.mutex.Unlock()
+	g.get(labelValues).value = value
+}
+
+// Add : Increases the value of the counter for the given label values
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.registry.mutex.Lock()
+	defer c.registry.mutex.Unlock()
+	c.get(labelValues).value += value
+}
+
+// Inc : Increases the value of the counter for the given label values by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Observe : Adds an observation to the histogram for the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.registry.mutex.Lock()
+	defer h.registry.mutex.Unlock()
+	s := h.get(labelValues)
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// WriteText : Writes all the metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	var out bytes.Buffer
+	r.mutex.Lock()
+	for _, f := range r.families {
+		f.writeText(&out)
+	}
+	r.mutex.Unlock()
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// WriteTextFile : Writes all the metrics to the given file, as expected by the textfile collector of node_exporter.
+// The file is replaced atomically, so that the collector never reads a partial file
+func (r *Registry) WriteTextFile(path string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err = r.WriteText(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeText : Writes the metric family, with its series sorted by label values
+func (f *family) writeText(out *bytes.Buffer) {
+	fmt.Fprintf(out, "# HELP %s %s\n", f.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	fmt.Fprintf(out, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			fmt.Fprintf(out, "%s%s %s\n", f.name, f.labelText(s.labelValues), formatFloat(s.value))
+			continue
+		}
+		for i, bound := range f.buckets {
+			fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, f.labelText(s.labelValues, "le", formatFloat(bound)),
+				s.counts[i])
+		}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, f.labelText(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(out, "%s_sum%s %s\n", f.name, f.labelText(s.labelValues), formatFloat(s.value))
+		fmt.Fprintf(out, "%s_count%s %d\n", f.name, f.labelText(s.labelValues), s.count)
+	}
+}
+
+// labelText : Formats the label pairs of a series, followed by the given extra pair (e.g. the bucket bound)
+func (f *family) labelText(labelValues []string, extra ...string) string {
+	var pairs []string
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	for i, label := range f.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, escaper.Replace(labelValues[i])))
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[0], extra[1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat : Formats a value as expected by the Prometheus text format
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
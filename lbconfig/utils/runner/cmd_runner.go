@@ -5,14 +5,42 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/metrics"
 )
 
 // Run : runs a command with the given arguments if this is available. Returns a tuple of he output of the command in the desired format and an error
 func Run(pathToCommand string, printRuntime bool, timeout time.Duration, v ...string) (output string, err error, stderr string) {
+	// Timeout implementation
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return RunContext(ctx, pathToCommand, printRuntime, v...)
+}
+
+// RunContext : same as @see Run, but the command is killed, together with all its child processes, once the given
+// context is done
+func RunContext(ctx context.Context, pathToCommand string, printRuntime bool, v ...string) (output string, err error, stderr string) {
+	return run(ctx, filepath.Base(pathToCommand), pathToCommand, printRuntime, v...)
+}
+
+// run : same as @see RunContext, but the runtime and the failures are exported with the given command name as label
+func run(ctx context.Context, name, pathToCommand string, printRuntime bool, v ...string) (output string, err error, stderr string) {
+	start := time.Now()
+	defer func() {
+		metrics.CommandDuration.Observe(time.Since(start).Seconds(), name)
+		if err != nil {
+			metrics.CommandFailures.Inc(name)
+		}
+	}()
 	var now int64
 	if printRuntime {
 		now = time.Now().UnixNano() / int64(time.Millisecond)
@@ -25,21 +53,16 @@ func Run(pathToCommand string, printRuntime bool, timeout time.Duration, v ...st
 		}()
 	}
 
-	// Timeout implementation
-	cmdContext := context.Background()
-	if timeout > 0 {
-		var cancel context.CancelFunc
-		cmdContext, cancel = context.WithTimeout(context.Background(), timeout)
-		defer cancel()
-	}
-
-	cmd := exec.CommandContext(cmdContext, pathToCommand, v...)
+	cmd := exec.Command(pathToCommand, v...)
 	var errBuff bytes.Buffer
 	var outBuff bytes.Buffer
 	cmd.Stderr = &errBuff
 	cmd.Stdout = &outBuff
+	// Run the command in its own process group, so that its child processes (e.g. the ones of [bash -c]) can be
+	// killed with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-	err = cmd.Run()
+	err = runInGroup(ctx, cmd)
 	if err != nil {
 		stdout := strings.TrimRight(errBuff.String(), "\r\n")
 		return outBuff.String(), err, stdout
@@ -49,7 +72,50 @@ func Run(pathToCommand string, printRuntime bool, timeout time.Duration, v ...st
 	return result, err, ""
 }
 
+// runInGroup : Runs the command and waits for it to finish. If the context is done first, the whole process group of
+// the command is killed and the error of the context is returned
+func runInGroup(ctx context.Context, cmd *exec.Cmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	finished := make(chan struct{})
+	killed := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// The negative PID targets the process group
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			killed <- true
+		case <-finished:
+			killed <- false
+		}
+	}()
+	err := cmd.Wait()
+	close(finished)
+	if <-killed {
+		return ctx.Err()
+	}
+	return err
+}
+
 // RunCommand : runs a command with pipes. Note that all the flags should be directly given to the commands.
 func RunCommand(pippedCommand string, printRuntime bool, timeout time.Duration) (string, error, string) {
-	return Run("bash", printRuntime, timeout, "-c", pippedCommand)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return RunCommandContext(ctx, "command", pippedCommand, printRuntime)
+}
+
+// RunCommandContext : same as @see RunCommand, but the command is killed, together with all its child processes, once
+// the given context is done. The metrics are exported under the given name rather than under [bash], since the
+// commands of the configuration files are arbitrary
+func RunCommandContext(ctx context.Context, name, pippedCommand string, printRuntime bool) (string, error, string) {
+	return run(ctx, name, "bash", printRuntime, "-c", pippedCommand)
 }
@@ -1,6 +1,7 @@
 package timer
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"runtime"
@@ -10,8 +11,62 @@ import (
 	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/parser"
 )
 
-// ExecuteWithTimeoutR : Executes a function given a maximum timeout value. If the timeout value is exceeded, a error
-// will be returned.
+// TimeoutError : Error returned when a function reaches the timeout value
+type TimeoutError struct {
+	Function string
+	Timeout  time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("the function [%s] has reached the timeout value of [%s]", e.Function, e.Timeout.String())
+}
+
+// ExecuteWithContext : Executes a function with a context that is cancelled once the timeout value is exceeded, so
+// that the function can stop its work (e.g. kill the processes it started). If the timeout value is exceeded, a
+// @see TimeoutError is returned without waiting for the function to return
+func ExecuteWithContext(ctx context.Context, timeout time.Duration, name string,
+	f func(ctx context.Context) (int, error)) (int, error) {
+	start := time.Now()
+	logger.WithFields(logger.Fields{
+		"FUNCTION_W_TIMEOUT": name,
+		"TIMEOUT_VALUE":      timeout.String()},
+	).Debug("Executing function...")
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type result struct {
+		value int
+		err   error
+	}
+	r := make(chan result, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				r <- result{-1, fmt.Errorf("the function [%s] panicked [%v]", name, recovered)}
+			}
+		}()
+		value, err := f(ctx)
+		r <- result{value, err}
+	}()
+
+	select {
+	case res := <-r:
+		logger.WithField("INTERNAL", "CMD_RUNNER").Debugf("Function [%s] :: Runtime: %dms", name,
+			time.Since(start)/time.Millisecond)
+		if res.err != nil {
+			return -1, res.err
+		}
+		return res.value, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, &TimeoutError{Function: name, Timeout: timeout}
+		}
+		return -1, ctx.Err()
+	}
+}
+
+// ExecuteWithTimeoutR : Executes a function given a maximum timeout value. If the timeout value is exceeded, a
+// @see TimeoutError will be returned.
 func ExecuteWithTimeoutR(timeout time.Duration, f interface{}, args ...interface{}) (ret interface{}, err error) {
 	now := time.Now().UnixNano() / int64(time.Millisecond)
 	fnName := getFunctionName(f)
@@ -29,8 +84,7 @@ func ExecuteWithTimeoutR(timeout time.Duration, f interface{}, args ...interface
 		logger.WithField("INTERNAL", "CMD_RUNNER").Debugf("Function [%s] :: Runtime: %dms", fnName, newNow)
 		return res, <-e
 	case <-time.After(timeout):
-		return nil, fmt.Errorf("the function [%s] has reached the timeout value of [%s]",
-			fnName, timeout.String())
+		return nil, &TimeoutError{Function: fnName, Timeout: timeout}
 	}
 }
 
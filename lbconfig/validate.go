@@ -0,0 +1,234 @@
+package lbconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// Severity : Level of a @see Diagnostic
+type Severity string
+
+// Severities of the diagnostics
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic : Problem found in a configuration file without evaluating it
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Message  string   `json:"message"`
+	Source   string   `json:"source,omitempty"`
+	// Rule is the lint rule that reported the diagnostic. Empty for the validation problems
+	Rule string `json:"rule,omitempty"`
+}
+
+// newDiagnostic : Creates a diagnostic from a positioned error
+func newDiagnostic(severity Severity, err *config.Error) Diagnostic {
+	return Diagnostic{
+		Severity: severity,
+		File:     err.Pos.File,
+		Line:     err.Pos.Line,
+		Column:   err.Pos.Column,
+		Message:  err.Msg,
+		Source:   err.Source,
+	}
+}
+
+func (d Diagnostic) String() string {
+	message := d.Message
+	if len(d.Rule) != 0 {
+		message += " [" + d.Rule + "]"
+	}
+	// The problems of a whole file have no line
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, message)
+	}
+	pos := config.Position{File: d.File, Line: d.Line, Column: d.Column}
+	return config.Errorf(pos, d.Source, "%s: %s", d.Severity, message).Error()
+}
+
+// HasErrors : Tells if any of the diagnostics is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// validator : Collects the diagnostics of a configuration file. The scope holds the variables defined so far, in both
+// branches of the blocks. If lint is set, the likely mistakes are reported too (see @see Lint), and seen holds the
+// checks evaluated before the current statement. Weighted tells if the loads are aggregated with their weights
+type validator struct {
+	scope       *config.Scope
+	diagnostics []Diagnostic
+	lint        bool
+	seen        map[string]*config.Action
+	weighted    bool
+}
+
+// Validate : Checks a configuration mapping (its file, its fragments and the files they include) without running any
+// check or load: the grammar, the keywords, the variables, and the arguments of the CLIs that can validate them (e.g.
+// the daemon JSON, the lemon and collectd expressions, the collectd alarms and the constants). Both branches of the
+// blocks are checked. The commands that cannot be found, and the weights of the loads that are not aggregated with
+// their weights, are reported as warnings
+func Validate(cm *mapping.ConfigurationMapping) []Diagnostic {
+	return validate(cm, false)
+}
+
+// validate : Validates a configuration mapping, and lints it if requested. See @see Validate and @see Lint
+func validate(cm *mapping.ConfigurationMapping, lint bool) []Diagnostic {
+	file, err := config.Load(cm.ConfigFilePath, cm.Fragments...)
+	if file == nil {
+		return []Diagnostic{{Severity: SeverityError, File: cm.ConfigFilePath, Message: err.Error()}}
+	}
+	v := &validator{scope: config.NewScope(facts(cm)), lint: lint, seen: make(map[string]*config.Action),
+		weighted: file.Aggregate != nil && file.Aggregate.Method == config.AggregateWeighted}
+	if list, ok := err.(config.ErrorList); ok {
+		for _, parseErr := range list {
+			v.diagnostics = append(v.diagnostics, newDiagnostic(SeverityError, parseErr))
+		}
+	}
+	v.statements(file.Statements)
+
+	sortDiagnostics(v.diagnostics)
+	return v.diagnostics
+}
+
+// sortDiagnostics : Sorts the diagnostics by file, line and column
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// statements : Checks the given statements, in the order of the file
+func (v *validator) statements(statements []config.Statement) {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *config.Action:
+			v.action(s)
+		case *config.Set:
+			value, err := v.scope.Expand(s.Value, s.ValuePos, s.Text)
+			if err != nil {
+				v.add(SeverityError, err)
+				value = s.Value
+			}
+			v.scope.Set(s.Name, value)
+		case *config.If:
+			v.condition(s)
+			// Only one of the branches is evaluated, so they cannot duplicate each other's checks. The checks of both
+			// branches are evaluated whatever the condition is
+			seen := v.seen
+			v.seen = copySeen(seen)
+			v.statements(s.Then)
+			then := v.seen
+			v.seen = copySeen(seen)
+			v.statements(s.Else)
+			v.seen = commonSeen(then, v.seen)
+		}
+	}
+}
+
+// action : Checks the keyword and the arguments of a check or load line
+func (v *validator) action(action *config.Action) {
+	args, err := v.scope.ExpandArgs(action)
+	if err != nil {
+		v.add(SeverityError, err)
+		return
+	}
+	expression, resolveErr := resolveAction(action)
+	if resolveErr != nil {
+		v.add(SeverityError, resolveErr.(*config.Error))
+		return
+	}
+	if v.lint {
+		v.lintAction(action, args, expression)
+	}
+	if action.Weight > 0 && !v.weighted {
+		v.add(SeverityWarning, config.Errorf(action.WeightPos, action.Text, "the weight is ignored unless the loads "+
+			"are aggregated with [aggregate weighted]"))
+	}
+
+	argsValidator, ok := expression.cli.(ArgumentsValidator)
+	if !ok {
+		if len(strings.TrimSpace(args)) != 0 {
+			v.add(SeverityWarning, config.Errorf(action.ArgsPos, action.Text, "the arguments are ignored by the [%s] "+
+				"%s", strings.ToLower(action.Keyword), action.Kind))
+		}
+		return
+	}
+	warnings, argsErr := argsValidator.ValidateArguments(args)
+	for _, warning := range warnings {
+		v.add(SeverityWarning, config.Errorf(action.ArgsPos, action.Text, "%s", warning))
+	}
+	if argsErr != nil {
+		v.add(SeverityError, config.Errorf(action.ArgsPos, action.Text, "invalid arguments for the [%s] %s: %v",
+			strings.ToLower(action.Keyword), action.Kind, argsErr))
+	}
+}
+
+// condition : Checks that the variables of the condition of a block are defined
+func (v *validator) condition(block *config.If) {
+	condition := block.Condition
+	if condition.Subject != "" {
+		if _, found := v.scope.Lookup(condition.Variable()); !found {
+			v.add(SeverityError, config.Errorf(condition.Position, block.Text, "the variable [%s] is not defined",
+				condition.Subject))
+		}
+	}
+	if condition.Regexp == nil {
+		if _, err := v.scope.Expand(condition.Value, condition.ValuePos, block.Text); err != nil {
+			v.add(SeverityError, err)
+		}
+	}
+}
+
+// add : Records a diagnostic
+func (v *validator) add(severity Severity, err *config.Error) {
+	v.diagnostics = append(v.diagnostics, newDiagnostic(severity, err))
+}
+
+// warn : Records a warning of a lint rule
+func (v *validator) warn(rule string, err *config.Error) {
+	diagnostic := newDiagnostic(SeverityWarning, err)
+	diagnostic.Rule = rule
+	v.diagnostics = append(v.diagnostics, diagnostic)
+}
+
+// Validate : Validates the configuration files found by the launcher (only the one given with [--checkconfig], if
+// any), without running any check or load. See @see Validate
+func (l *AppLauncher) Validate() ([]Diagnostic, error) {
+	lbConfMappings, err := mapping.ReadLBConfigFiles(l.AppOptions)
+	if err != nil {
+		return nil, err
+	}
+	// The default configuration file may be split per alias, but it only needs to be validated once
+	validated := make(map[string]bool)
+	var diagnostics []Diagnostic
+	for _, cm := range lbConfMappings {
+		key := fmt.Sprintf("%s%v", cm.ConfigFilePath, cm.Fragments)
+		if validated[key] {
+			continue
+		}
+		validated[key] = true
+		diagnostics = append(diagnostics, Validate(cm)...)
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,163 @@
+package ci
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/snmp"
+)
+
+// stubMasterAgent : minimal AgentX master agent, that accepts a single subagent session
+type stubMasterAgent struct {
+	t        *testing.T
+	conn     net.Conn
+	packetID uint32
+}
+
+// request : sends a PDU to the subagent and returns its response
+func (m *stubMasterAgent) request(packet *snmp.AgentXPacket) *snmp.AgentXPacket {
+	m.packetID++
+	packet.SessionID, packet.PacketID = 42, m.packetID
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	if _, err = m.conn.Write(data); err != nil {
+		m.t.Fatal(err)
+	}
+	response, err := snmp.ReadAgentXPacket(m.conn)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	if response.Type != snmp.AgentXResponse || response.PacketID != m.packetID {
+		m.t.Fatalf("Unexpected response [%+v]", response)
+	}
+	return response
+}
+
+// accept : waits for the subagent to open a session and register its subtree
+func (m *stubMasterAgent) accept(listener net.Listener) *snmp.AgentXPacket {
+	conn, err := listener.Accept()
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(defaultTimeout))
+	m.conn = conn
+
+	var register *snmp.AgentXPacket
+	for _, expected := range []snmp.AgentXType{snmp.AgentXOpen, snmp.AgentXRegister} {
+		packet, err := snmp.ReadAgentXPacket(conn)
+		if err != nil {
+			m.t.Fatal(err)
+		}
+		if packet.Type != expected {
+			m.t.Fatalf("Expected the PDU [%d] but got [%d]", expected, packet.Type)
+		}
+		register = packet
+		data, _ := (&snmp.AgentXPacket{Type: snmp.AgentXResponse, SessionID: 42, PacketID: packet.PacketID}).MarshalBinary()
+		if _, err = conn.Write(data); err != nil {
+			m.t.Fatal(err)
+		}
+	}
+	return register
+}
+
+func TestAgentX(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir, err := ioutil.TempDir("/tmp", "lbclient_agentx_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "master")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	table := createTestTable(t)
+	base, _ := snmp.ParseOID(".1.3.6.1.4.1.96.255.1")
+	stop := make(chan struct{})
+	defer close(stop)
+	go snmp.NewAgentX(socket, base, func() *snmp.Table { return table }).Run(stop)
+
+	master := &stubMasterAgent{t: t}
+	register := master.accept(listener)
+	if register.Subtree.Compare(base) != 0 || register.SessionID != 42 {
+		logger.Errorf("Expected the registration of [%s] in the session [42] but got [%s] in [%d]",
+			base, register.Subtree, register.SessionID)
+		t.Fail()
+	}
+
+	alias, _ := snmp.ParseOID(".1.3.6.1.4.1.96.255.1.2")
+	missing, _ := snmp.ParseOID(".1.3.6.1.4.1.96.255.1.3")
+	parent, _ := snmp.ParseOID(".1.3.6.1.4.1.96")
+
+	t.Run("Get", func(t *testing.T) {
+		response := master.request(&snmp.AgentXPacket{Type: snmp.AgentXGet, SearchRanges: []snmp.AgentXSearchRange{
+			{Start: base}, {Start: alias}, {Start: missing}}})
+		expected := []snmp.AgentXVarBind{
+			{Type: snmp.AgentXOctetString, Name: base, Value: "first.cern.ch=5,second.cern.ch=-13"},
+			{Type: snmp.AgentXInteger, Name: alias, Value: int64(-13)},
+			{Type: snmp.AgentXNoSuchObject, Name: missing},
+		}
+		checkVarBinds(t, expected, response.VarBinds)
+	})
+	t.Run("GetNext", func(t *testing.T) {
+		response := master.request(&snmp.AgentXPacket{Type: snmp.AgentXGetNext, SearchRanges: []snmp.AgentXSearchRange{
+			{Start: parent}, {Start: base, Include: true}, {Start: base, End: base.Append(1)}, {Start: alias}}})
+		expected := []snmp.AgentXVarBind{
+			{Type: snmp.AgentXOctetString, Name: base, Value: "first.cern.ch=5,second.cern.ch=-13"},
+			{Type: snmp.AgentXOctetString, Name: base, Value: "first.cern.ch=5,second.cern.ch=-13"},
+			{Type: snmp.AgentXEndOfMibView, Name: base},
+			{Type: snmp.AgentXEndOfMibView, Name: alias},
+		}
+		checkVarBinds(t, expected, response.VarBinds)
+	})
+	t.Run("GetBulk", func(t *testing.T) {
+		response := master.request(&snmp.AgentXPacket{Type: snmp.AgentXGetBulk, MaxRepetitions: 5,
+			SearchRanges: []snmp.AgentXSearchRange{{Start: base}}})
+		expected := []snmp.AgentXVarBind{
+			{Type: snmp.AgentXInteger, Name: base.Append(1), Value: int64(5)},
+			{Type: snmp.AgentXInteger, Name: alias, Value: int64(-13)},
+			{Type: snmp.AgentXEndOfMibView, Name: alias},
+		}
+		checkVarBinds(t, expected, response.VarBinds)
+	})
+	t.Run("Ping", func(t *testing.T) {
+		response := master.request(&snmp.AgentXPacket{Type: snmp.AgentXPing})
+		if response.Error != snmp.AgentXNoError || len(response.VarBinds) != 0 {
+			logger.Errorf("Expected an empty response without error but got [%+v]", response)
+			t.Fail()
+		}
+	})
+	t.Run("TestSet", func(t *testing.T) {
+		response := master.request(&snmp.AgentXPacket{Type: snmp.AgentXTestSet, VarBinds: []snmp.AgentXVarBind{
+			{Type: snmp.AgentXInteger, Name: alias, Value: int64(1)}}})
+		if response.Error != snmp.AgentXNotWritable {
+			logger.Errorf("Expected the error [notWritable] but got [%d]", response.Error)
+			t.Fail()
+		}
+	})
+}
+
+// checkVarBinds : compares the received variable bindings with the expected ones
+func checkVarBinds(t *testing.T, expected, received []snmp.AgentXVarBind) {
+	if len(expected) != len(received) {
+		logger.Errorf("Expected the variables [%+v] but got [%+v]", expected, received)
+		t.FailNow()
+	}
+	for i := range expected {
+		if expected[i].Type != received[i].Type || expected[i].Name.Compare(received[i].Name) != 0 ||
+			expected[i].Value != received[i].Value {
+			logger.Errorf("Expected the variable [%+v] but got [%+v]", expected[i], received[i])
+			t.Fail()
+		}
+	}
+}
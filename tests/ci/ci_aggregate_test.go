@@ -0,0 +1,115 @@
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// TestAggregate : the loads should be combined with the aggregation of the file, and added up by default
+func TestAggregate(t *testing.T) {
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf": "include common.conf\nload constant 1",
+		"common.conf":   "aggregate max\nload constant 2",
+	})
+	defer os.RemoveAll(dir)
+
+	myTests := []lbTest{
+		{title: "DefaultSum", configurationContent: "load constant 10\nload constant 15", expectedMetricValue: 25},
+		{title: "Sum", configurationContent: "aggregate sum\nload constant 10\nload constant 15",
+			expectedMetricValue: 25},
+		{title: "Max", configurationContent: "load constant 10\nload constant 30\nload constant 15\nAGGREGATE MAX",
+			expectedMetricValue: 30},
+		{title: "Min", configurationContent: "aggregate min\nload constant 10\nload constant 30\nload constant 15",
+			expectedMetricValue: 10},
+		{title: "Avg", configurationContent: "aggregate avg\nload constant 10\nload constant 15",
+			expectedMetricValue: 13},
+		{title: "Weighted", configurationContent: "aggregate weighted\nload constant 10 weight=0.7\n" +
+			"load constant 30 WEIGHT=0.3 timeout=5s", expectedMetricValue: 16},
+		{title: "WeightedDefaultsToOne", configurationContent: "aggregate weighted\nload constant 10 weight=3\n" +
+			"load constant 30", expectedMetricValue: 15},
+		{title: "WeightIgnoredBySum", configurationContent: "load constant 10 weight=3\nload constant 30",
+			expectedMetricValue: 40},
+		{title: "ChecksAreNotAggregated", configurationContent: "aggregate min\ncheck command true\n" +
+			"load constant 10\nload constant 30", expectedMetricValue: 10},
+		{title: "InvalidWeight", configurationContent: "aggregate weighted\nload constant 10 weight=-1",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "UnknownAggregation", configurationContent: "aggregate median\nload constant 10",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "DuplicatedAggregation", configurationContent: "aggregate max\naggregate min\nload constant 10",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "AggregationInsideBlock", configurationContent: "if alias =~ /a/ {\n  aggregate max\n}\n" +
+			"load constant 1", expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "AggregationInIncludedFile", configuration: filepath.Join(dir, "lbclient.conf"),
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+	}
+
+	runMultipleTests(t, myTests)
+}
+
+// TestAggregateExplain : the trace should show the aggregated load after every line, and the formula of the total
+func TestAggregateExplain(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "aggregate weighted\nload constant 10 weight=0.7\ncheck command true\n"+
+		"load constant 30 weight=0.3\n", "explain", "--format", "json")
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	if err := launcher.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	var reports []lbconfig.ExplainReport
+	if err := json.Unmarshal(out.Bytes(), &reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].MetricValue != 16 || reports[0].Formula != "(0.7*10 + 0.3*30) / 1 = 16" {
+		t.Fatalf("Unexpected reports [%+v]", reports)
+	}
+	for i, expected := range []int{10, 10, 16} {
+		if reports[0].Lines[i].Total != expected {
+			logger.Errorf("Expected the total [%d] after the line [%d] but got [%d]", expected,
+				reports[0].Lines[i].Number, reports[0].Lines[i].Total)
+			t.Fail()
+		}
+	}
+
+	launcher, dir = createDaemonLauncher(t, "aggregate max\nload constant 12\nload constant 30\n", "explain")
+	defer os.RemoveAll(dir)
+	out.Reset()
+	if err := launcher.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Loads aggregated as [max(12, 30) = 30]\n") {
+		logger.Errorf("Expected the formula in the table [%s]", out.String())
+		t.Fail()
+	}
+}
+
+// TestAggregateValidation : the weights should only be given to loads aggregated with their weights
+func TestAggregateValidation(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"ignored.conf":  "aggregate max\nload constant 10 weight=2\nload constant 20",
+		"weighted.conf": "aggregate weighted\nload constant 10 weight=2\nload constant 20",
+	})
+	defer os.RemoveAll(dir)
+
+	diagnostics := lbconfig.Validate(mapping.NewConfiguration(filepath.Join(dir, "ignored.conf")))
+	if len(diagnostics) != 1 || diagnostics[0].Severity != lbconfig.SeverityWarning ||
+		diagnostics[0].Line != 2 || diagnostics[0].Column != 18 {
+		logger.Errorf("Expected a warning for the ignored weight but got %v", diagnostics)
+		t.Fail()
+	}
+	diagnostics = lbconfig.Validate(mapping.NewConfiguration(filepath.Join(dir, "weighted.conf")))
+	if len(diagnostics) != 0 {
+		logger.Errorf("Expected no diagnostic but got %v", diagnostics)
+		t.Fail()
+	}
+}
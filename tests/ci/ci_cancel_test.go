@@ -0,0 +1,72 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/runner"
+)
+
+// TestTimeoutKillsProcessGroup : a check that reaches its timeout should have all its processes killed
+func TestTimeoutKillsProcessGroup(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir, err := ioutil.TempDir("/tmp", "lbclient_cancel_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "marker")
+	configuration := filepath.Join(dir, "lbclient.conf")
+	// The subshell is a child of [bash -c], so it is only killed together with the process group
+	content := fmt.Sprintf("check command (sleep 1; touch %s) timeout=200ms\nload constant 5", marker)
+	if err = ioutil.WriteFile(configuration, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := mapping.NewConfiguration(configuration)
+	start := time.Now()
+	if err = lbconfig.Evaluate(cm, defaultTimeout, false); err == nil {
+		t.Fatal("Expected the evaluation to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		logger.Errorf("Expected the evaluation to stop after the timeout, but it took [%s]", elapsed)
+		t.Fail()
+	}
+	if !cm.Results[0].TimedOut || cm.MetricValue != -14 {
+		logger.Errorf("Expected the line to time out but got [%+v] with the metric [%d]", cm.Results[0],
+			cm.MetricValue)
+		t.Fail()
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if _, err = os.Stat(marker); err == nil {
+		logger.Error("The processes of the check kept running after the timeout")
+		t.Fail()
+	}
+}
+
+// TestRunnerContext : the runner should kill the command once the context is cancelled
+func TestRunnerContext(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err, _ := runner.RunCommandContext(ctx, "command", "sleep 5 | cat", false)
+	if err != context.DeadlineExceeded {
+		logger.Errorf("Expected the error [%v] but got [%v]", context.DeadlineExceeded, err)
+		t.Fail()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		logger.Errorf("Expected the command to be killed after the timeout, but it took [%s]", elapsed)
+		t.Fail()
+	}
+}
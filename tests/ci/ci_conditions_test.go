@@ -0,0 +1,65 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// TestConditionalBlocks : only the statements of the blocks whose condition holds should be evaluated
+func TestConditionalBlocks(t *testing.T) {
+	myTests := []lbTest{
+		{title: "FileExists", configurationContent: "if file_exists / {\n  load constant 5\n} else {\n  load constant 6\n}",
+			expectedMetricValue: 5},
+		{title: "FileDoesNotExist", configurationContent: "if !file_exists / {\n  load constant 5\n} else {\n" +
+			"  load constant 6\n}", expectedMetricValue: 6},
+		{title: "Hostname", configurationContent: "if hostname =~ /./ {\n  load constant 7\n}\nload constant 1",
+			expectedMetricValue: 8},
+		{title: "ElseIf", configurationContent: "set ROLE = db\nif ROLE == web {\n  load constant 1\n" +
+			"} else if ROLE == db {\n  load constant 2\n} else {\n  load constant 3\n}\nload constant 10",
+			expectedMetricValue: 12},
+		{title: "UndefinedVariable", configurationContent: "if ROLE_UNSET !~ /x/ {\n}\n", expectedMetricValue: -1,
+			shouldFail: true, validateConfig: true},
+		{title: "NestedBlocks", configurationContent: "set A = 1\nif A == 1 {\n  if A != 1 {\n    check command false\n" +
+			"  } else {\n    load constant 3\n  }\n  load constant 4\n}", expectedMetricValue: 7},
+		{title: "ExpandedPath", configurationContent: "set DIR = /\nif file_exists ${DIR} {\n  load constant 9\n}",
+			expectedMetricValue: 9},
+		{title: "MissingBrace", configurationContent: "if alias =~ /a/ {\n  load constant 1",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "UnexpectedBrace", configurationContent: "load constant 1\n}", expectedMetricValue: -1,
+			shouldFail: true, validateConfig: true},
+		{title: "InvalidRegexp", configurationContent: "if alias =~ /(/ {\n  load constant 1\n}",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "BudgetInsideBlock", configurationContent: "if alias =~ /a/ {\n  timeout 1m\n}\nload constant 1",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+	}
+
+	runMultipleTests(t, myTests)
+}
+
+// TestAliasConditions : the default configuration file should give a metric value per alias when it has alias blocks
+func TestAliasConditions(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf": "if alias =~ /^a\\./ {\n  load constant 1\n} else {\n  load constant 2\n}",
+		"lbaliases":     "lbalias=a.cern.ch\nlbalias=b.cern.ch\n",
+	})
+	defer os.RemoveAll(dir)
+
+	launcher := lbconfig.NewAppLauncher()
+	if err := launcher.ParseApplicationArguments([]string{"--cm", dir, "--ca",
+		filepath.Join(dir, "lbaliases")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a.cern.ch=1,b.cern.ch=2"
+	if launcher.MetricValue != expected {
+		logger.Errorf("Expected the output [%s] but got [%s]", expected, launcher.MetricValue)
+		t.Fail()
+	}
+}
@@ -0,0 +1,113 @@
+package ci
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// createDaemonLauncher : creates a launcher whose configuration directory only contains the given default
+// configuration, for the alias [test.cern.ch]
+func createDaemonLauncher(t *testing.T, configurationContent string, args ...string) (*lbconfig.AppLauncher, string) {
+	dir, err := ioutil.TempDir("/tmp", "lbclient_daemon_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "lbaliases"), []byte("lbalias=test.cern.ch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "lbclient.conf"), []byte(configurationContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	launcher := lbconfig.NewAppLauncher()
+	args = append([]string{"--cm", dir, "--ca", filepath.Join(dir, "lbaliases"), "--daemon"}, args...)
+	if err = launcher.ParseApplicationArguments(args); err != nil {
+		t.Fatal(err)
+	}
+	return launcher, dir
+}
+
+// TestDaemonServesCachedOutput : the polls should be answered from the cache while it is within the maximum age
+func TestDaemonServesCachedOutput(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "load constant 5", "--daemon.maxage", "1h")
+	defer os.RemoveAll(dir)
+
+	daemon := lbconfig.NewDaemon(launcher)
+	daemon.Refresh()
+	// The new value should not be seen until the cache expires
+	if err := ioutil.WriteFile(filepath.Join(dir, "lbclient.conf"), []byte("load constant 6"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := daemon.Serve(strings.NewReader("poll\npoll\n"), &out, ".1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	expected := ".1.2.3\ninteger\n5\n.1.2.3\ninteger\n5\n"
+	if out.String() != expected {
+		logger.Errorf("Expected the daemon output [%q] but got [%q]", expected, out.String())
+		t.Fail()
+	}
+}
+
+// TestDaemonRefreshesExpiredCache : the polls should trigger a new evaluation once the cache is too old
+func TestDaemonRefreshesExpiredCache(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "load constant 5", "--daemon.maxage", "1ns")
+	defer os.RemoveAll(dir)
+
+	daemon := lbconfig.NewDaemon(launcher)
+	if snapshot := daemon.Latest(); snapshot.MetricValue != "5" {
+		logger.Errorf("Expected the metric value [5] but got [%s]", snapshot.MetricValue)
+		t.Fail()
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "lbclient.conf"), []byte("load constant 6"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot := daemon.Latest(); snapshot.MetricValue != "6" {
+		logger.Errorf("Expected the refreshed metric value [6] but got [%s]", snapshot.MetricValue)
+		t.Fail()
+	}
+}
+
+// TestDaemonMissingConfiguration : the polls should be answered even if no metric can be calculated
+func TestDaemonMissingConfiguration(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "load constant 5")
+	defer os.RemoveAll(dir)
+	launcher.AppOptions.LbAliasFile = filepath.Join(dir, "does_not_exist")
+
+	var out bytes.Buffer
+	if err := lbconfig.NewDaemon(launcher).Serve(strings.NewReader("poll\n"), &out, ".1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "NONE\n" {
+		logger.Errorf("Expected the daemon output [NONE] but got [%q]", out.String())
+		t.Fail()
+	}
+}
+
+// TestDaemonInvalidDurations : the non-positive interval and maximum age should be rejected with the arguments
+func TestDaemonInvalidDurations(t *testing.T) {
+	for _, args := range [][]string{
+		{"--daemon.interval", "0s"},
+		{"--daemon.interval", "-30s"},
+		{"--daemon.maxage", "0s"},
+		{"--daemon.maxage", "-1m"},
+	} {
+		launcher := lbconfig.NewAppLauncher()
+		err := launcher.ParseApplicationArguments(append([]string{"--daemon"}, args...))
+		if err == nil || !strings.Contains(err.Error(), "must be positive") {
+			logger.Errorf("Expected the arguments %v to be rejected but got the error [%v]", args, err)
+			t.Fail()
+		}
+	}
+}
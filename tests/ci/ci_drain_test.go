@@ -0,0 +1,132 @@
+package ci
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// TestDrain : the load of a drained alias should be raised during the drain, and the alias excluded at its end
+func TestDrain(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	myTests := []struct {
+		title    string
+		files    map[string]string
+		age      time.Duration
+		expected string
+	}{
+		{title: "NotDrained", files: map[string]string{"drain.other.cern.ch": "10m"}, expected: "5"},
+		{title: "Started", files: map[string]string{"drain.test.cern.ch": "100m"}, expected: "5"},
+		{title: "Halfway", files: map[string]string{"drain.test.cern.ch": "100m\n"}, age: 50 * time.Minute,
+			expected: "505"},
+		{title: "AllAliases", files: map[string]string{"drain": "100m"}, age: 25 * time.Minute, expected: "255"},
+		{title: "MostAdvanced", files: map[string]string{"drain": "100m", "drain.test.cern.ch": "50m"},
+			age: 25 * time.Minute, expected: "505"},
+		{title: "Ended", files: map[string]string{"drain.test.cern.ch": "10m"}, age: time.Hour, expected: "-20"},
+		{title: "Empty", files: map[string]string{"drain.test.cern.ch": ""}, expected: "-20"},
+		{title: "InvalidDuration", files: map[string]string{"drain.test.cern.ch": "soon"}, expected: "-20"},
+	}
+
+	for _, myTest := range myTests {
+		t.Run(myTest.title, func(t *testing.T) {
+			drainDir, err := ioutil.TempDir("/tmp", "lbclient_drain_test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(drainDir)
+			for name, content := range myTest.files {
+				path := filepath.Join(drainDir, name)
+				if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				modified := time.Now().Add(-myTest.age)
+				if err = os.Chtimes(path, modified, modified); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			launcher, dir := createDaemonLauncher(t, "load constant 5\n", "--drain.dir", drainDir)
+			defer os.RemoveAll(dir)
+			if err = launcher.Run(); err != nil {
+				t.Fatal(err)
+			}
+			if launcher.MetricValue != myTest.expected {
+				logger.Errorf("Expected the output [%s] but got [%s]", myTest.expected, launcher.MetricValue)
+				t.Fail()
+			}
+			formula := launcher.Mappings()[0].Formula
+			if myTest.expected == "505" && !strings.HasSuffix(formula, "+ drain(500) = 505") {
+				logger.Errorf("Expected the drain in the formula [%s]", formula)
+				t.Fail()
+			}
+		})
+	}
+}
+
+// TestDrainExcludedAlias : an alias already excluded by its checks should keep its failure code
+func TestDrainExcludedAlias(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	drainDir, err := ioutil.TempDir("/tmp", "lbclient_drain_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(drainDir)
+	if err = ioutil.WriteFile(filepath.Join(drainDir, "drain"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	launcher, dir := createDaemonLauncher(t, "check command false\nload constant 5\n", "--drain.dir", drainDir)
+	defer os.RemoveAll(dir)
+	if err = launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if launcher.MetricValue != "-14" {
+		logger.Errorf("Expected the output [-14] but got [%s]", launcher.MetricValue)
+		t.Fail()
+	}
+}
+
+// TestDrainSharedConfiguration : the drain of an alias should not affect the other aliases of the default configuration
+// file
+func TestDrainSharedConfiguration(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf":         "load constant 5",
+		"lbaliases":             "lbalias=a.cern.ch\nlbalias=b.cern.ch\n",
+		"drain/drain.a.cern.ch": "",
+		"other/drain.c.cern.ch": "",
+	})
+	defer os.RemoveAll(dir)
+
+	for drainDir, expected := range map[string]string{
+		filepath.Join(dir, "drain"): "a.cern.ch=-20,b.cern.ch=5",
+		filepath.Join(dir, "other"): "5",
+	} {
+		launcher := lbconfig.NewAppLauncher()
+		if err := launcher.ParseApplicationArguments([]string{"--cm", dir, "--ca", filepath.Join(dir, "lbaliases"),
+			"--drain.dir", drainDir}); err != nil {
+			t.Fatal(err)
+		}
+		if err := launcher.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if launcher.MetricValue != expected {
+			logger.Errorf("Expected the output [%s] but got [%s]", expected, launcher.MetricValue)
+			t.Fail()
+		}
+	}
+}
+
+// TestDrainInvalidExtra : the negative extra load should be rejected with the arguments
+func TestDrainInvalidExtra(t *testing.T) {
+	launcher := lbconfig.NewAppLauncher()
+	if err := launcher.ParseApplicationArguments([]string{"--drain.extra", "-1000"}); err == nil {
+		logger.Error("Expected the negative extra load of the drain to be rejected")
+		t.Fail()
+	}
+}
@@ -0,0 +1,61 @@
+package ci
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// TestDropInDirectories : the fragments of the drop-in directories should be merged in lexical order, and an alias
+// can be configured with its drop-in directory only
+func TestDropInDirectories(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createAliasesLauncher(t, map[string]string{"a.cern.ch": "load constant 1"}, "explain")
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"lbaliases":                           "lbalias=a.cern.ch\nlbalias=b.cern.ch\nlbalias=c.cern.ch\n",
+		"lbclient.conf.a.cern.ch.d/20-b.conf": "load constant 20",
+		"lbclient.conf.a.cern.ch.d/10-a.conf": "check command false",
+		"lbclient.conf.a.cern.ch.d/notes.txt": "load constant 5000",
+		"lbclient.conf.b.cern.ch.d/b.conf":    "load constant 300",
+		"lbclient.conf.d/default.conf":        "load constant 4000",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first fragment of [a] fails, so the second one is not evaluated
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a.cern.ch=-14,b.cern.ch=300,c.cern.ch=4000"
+	if launcher.MetricValue != expected {
+		logger.Errorf("Expected the output [%s] but got [%s]", expected, launcher.MetricValue)
+		t.Fail()
+	}
+	results := launcher.Mappings()[0].Results
+	if len(results) != 2 || results[1].File != filepath.Join(dir, "lbclient.conf.a.cern.ch.d/10-a.conf") {
+		logger.Errorf("Unexpected results [%+v]", results)
+		t.Fail()
+	}
+
+	// The explain output shows the fragment of every line
+	var out bytes.Buffer
+	if err := launcher.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "10-a.conf:1 ") || !strings.Contains(out.String(), "Drop-in fragments [") {
+		logger.Errorf("Expected the fragments in the output [%s]", out.String())
+		t.Fail()
+	}
+}
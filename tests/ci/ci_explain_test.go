@@ -0,0 +1,94 @@
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+const explainConfiguration = "# comment\nload constant 5\ncheck command false\nbogus line\nload constant 7\n"
+
+// explainReports : runs the explain command with the given arguments and decodes its JSON output
+func explainReports(t *testing.T, args ...string) []lbconfig.ExplainReport {
+	launcher, dir := createDaemonLauncher(t, explainConfiguration,
+		append([]string{"explain", "--format", "json"}, args...)...)
+	defer os.RemoveAll(dir)
+	if launcher.AppOptions.Command != "explain" {
+		t.Fatalf("Expected the command [explain] but got [%s]", launcher.AppOptions.Command)
+	}
+
+	var out bytes.Buffer
+	if err := launcher.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	var reports []lbconfig.ExplainReport
+	if err := json.Unmarshal(out.Bytes(), &reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected a single report but got [%d]", len(reports))
+	}
+	return reports
+}
+
+// TestExplainStopsAtFirstFailure : without keep-going, the trace should end with the line that excluded the node
+func TestExplainStopsAtFirstFailure(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	report := explainReports(t)[0]
+	if report.MetricValue != -14 || report.FailureCode != 14 || len(report.Lines) != 2 {
+		t.Fatalf("Unexpected report [%+v]", report)
+	}
+	if report.Lines[0].Number != 2 || report.Lines[0].Total != 5 ||
+		report.Lines[1].Number != 3 || !report.Lines[1].Excluded || report.Lines[1].Action != "COMMAND" {
+		logger.Errorf("Unexpected lines [%+v]", report.Lines)
+		t.Fail()
+	}
+}
+
+// TestExplainKeepGoing : with keep-going, all the lines should be evaluated but the first failure should still decide
+// the metric value
+func TestExplainKeepGoing(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	report := explainReports(t, "--keep-going", "--alias", "test.cern.ch")[0]
+	if report.MetricValue != -14 || report.FailureCode != 14 || len(report.Lines) != 4 {
+		t.Fatalf("Unexpected report [%+v]", report)
+	}
+	invalid, last := report.Lines[2], report.Lines[3]
+	if invalid.Kind != "invalid" || invalid.Excluded || len(invalid.Error) == 0 {
+		logger.Errorf("Unexpected trace of the invalid line [%+v]", invalid)
+		t.Fail()
+	}
+	if last.Number != 5 || last.Result != 7 || last.Total != 12 || last.Excluded {
+		logger.Errorf("Unexpected trace of the last line [%+v]", last)
+		t.Fail()
+	}
+}
+
+// TestExplainTable : the table should show the status of every line
+func TestExplainTable(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, explainConfiguration, "explain", "-k")
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	if err := launcher.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"EXCLUDED", "FAILED", "Metric value [-14] (failure code [14])"} {
+		if !strings.Contains(out.String(), expected) {
+			logger.Errorf("Expected [%s] in the explain output [%s]", expected, out.String())
+			t.Fail()
+		}
+	}
+
+	launcher.AppOptions.Explain.Alias = "unknown.cern.ch"
+	if err := launcher.Explain(&out); err == nil {
+		logger.Error("Expected an error when explaining an unknown alias")
+		t.Fail()
+	}
+}
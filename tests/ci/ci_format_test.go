@@ -0,0 +1,122 @@
+package ci
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// TestFormat : the configuration files should be rewritten in their canonical form, keeping their comments
+func TestFormat(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	myTests := []struct {
+		title    string
+		content  string
+		expected string
+	}{
+		{title: "Canonical", content: "check roger\nload constant 5\n", expected: "check roger\nload constant 5\n"},
+		{title: "Keywords", content: "CHECK NoLogin\nLoad   CONSTANT   5   TIMEOUT=5s", expected: "check nologin\n" +
+			"load constant 5 timeout=5s\n"},
+		{title: "LegacyMetrics", content: "check lemon _20003 = 1\nload lemon _13163:2*${FACTOR_5} + [13163]\n" +
+			"load collectd [cpu_0/percent-idle]   /  10",
+			expected: "check lemon [20003] == 1\nload lemon [13163:2]*${FACTOR_5} + [13163]\n" +
+				"load collectd [cpu_0/percent-idle] / 10\n"},
+		{title: "DaemonJSON", content: "check daemon   {\"protocol\":\"tcp\",\n" +
+			"check daemon {\"protocol\":\"tcp\",   \"port\": [22, 80]}\ncheck collectd_alarms [ {\"a\":\"okay\"} ]",
+			expected: "check daemon {\"protocol\":\"tcp\",\ncheck daemon {\"port\": [22, 80], \"protocol\": \"tcp\"}\n" +
+				"check collectd_alarms [{\"a\": \"okay\"}]\n"},
+		{title: "Commands", content: "check command  echo \"a   b\"  ", expected: "check command echo \"a   b\"\n"},
+		{title: "Comments", content: "# Header\n\n\n\ntimeout 1m\n   # Indented\nset  X  =  1\n" +
+			"IF ALIAS =~ /^web/ {\n      load constant 1\n# Before the else\n} ELSE if !FILE_EXISTS /x {\n" +
+			"load constant 2\n} else {\n  # Before the end\n}\n# Footer",
+			expected: "# Header\n\ntimeout 1m\n# Indented\nset X = 1\nif alias =~ /^web/ {\n  load constant 1\n" +
+				"  # Before the else\n} else if !file_exists /x {\n  load constant 2\n} else {\n  # Before the end\n}\n" +
+				"# Footer\n"},
+		{title: "Aggregation", content: "AGGREGATE  Weighted\nload constant 5 TIMEOUT=5s Weight=0.50\n" +
+			"load constant 6 weight=2",
+			expected: "aggregate weighted\nload constant 5 weight=0.5 timeout=5s\nload constant 6 weight=2\n"},
+		{title: "Penalties", content: "Penalize  COMMAND false timeout=1s PENALTY=050\npenalize nologin\n",
+			expected: "penalize command false penalty=50 timeout=1s\npenalize nologin\n"},
+		{title: "NestedBlocks", content: "if A == 1 {\nif B != 2 {\ncheck roger\n}\n}", expected: "if A == 1 {\n" +
+			"  if B != 2 {\n    check roger\n  }\n}\n"},
+	}
+
+	for _, myTest := range myTests {
+		t.Run(myTest.title, func(t *testing.T) {
+			dir := createFiles(t, map[string]string{"lbclient.conf": myTest.content})
+			defer os.RemoveAll(dir)
+			formatted, err := lbconfig.FormatFile(filepath.Join(dir, "lbclient.conf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(formatted) != myTest.expected {
+				logger.Errorf("Expected the formatted file [%s] but got [%s]", myTest.expected, formatted)
+				t.Fail()
+			}
+		})
+	}
+
+	dir := createFiles(t, map[string]string{"lbclient.conf": "check roger\nchek nologin\n"})
+	defer os.RemoveAll(dir)
+	if _, err := lbconfig.FormatFile(filepath.Join(dir, "lbclient.conf")); err == nil {
+		logger.Error("The files with errors should not be formatted")
+		t.Fail()
+	}
+}
+
+// TestFormatCommand : the fmt command should format all the configuration files of the directory, and only list them
+// with [--check]
+func TestFormatCommand(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf":              "include common.conf\nLOAD constant 1\n",
+		"common.conf":                "check   roger\n",
+		"lbclient.conf.d/extra.conf": "check nologin\n",
+		"lbclient.conf.a.cern.ch":    "load constant 2\n",
+		"lbaliases":                  "lbalias=a.cern.ch\nlbalias=b.cern.ch\n",
+	})
+	defer os.RemoveAll(dir)
+
+	run := func(args ...string) (bool, string) {
+		launcher := lbconfig.NewAppLauncher()
+		if err := launcher.ParseApplicationArguments(append([]string{"--cm", dir, "--ca",
+			filepath.Join(dir, "lbaliases"), "fmt"}, args...)); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		formatted, err := launcher.Format(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return formatted, out.String()
+	}
+
+	expected := filepath.Join(dir, "common.conf") + "\n" + filepath.Join(dir, "lbclient.conf") + "\n"
+	if formatted, out := run("--check"); formatted || out != expected {
+		logger.Errorf("Expected the unformatted files [%s] but got [%s]", expected, out)
+		t.Fail()
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(dir, "common.conf")); string(content) != "check   roger\n" {
+		logger.Error("The check should not rewrite the files")
+		t.Fail()
+	}
+	if formatted, out := run(); formatted || out != expected {
+		logger.Errorf("Expected the rewritten files [%s] but got [%s]", expected, out)
+		t.Fail()
+	}
+	if formatted, out := run("--check"); !formatted || len(out) != 0 {
+		logger.Errorf("Expected all the files to be formatted but got [%s]", out)
+		t.Fail()
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(dir, "lbclient.conf")); !strings.HasPrefix(string(content),
+		"include common.conf\nload constant 1") {
+		logger.Errorf("Unexpected formatted file [%s]", content)
+		t.Fail()
+	}
+}
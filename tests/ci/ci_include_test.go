@@ -0,0 +1,113 @@
+package ci
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// createFiles : creates a temporary directory with the given files, indexed by their relative path
+func createFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("/tmp", "lbclient_include_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestInclude : the included files should be evaluated in place of the include statements, and their errors should
+// point at their own lines
+func TestInclude(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf":          "include common.conf\ninclude fragments/*.conf\nload constant 1",
+		"common.conf":            "check command true\nload constant 10",
+		"fragments/a.conf":       "load constant 100\ninclude ../nested/b.conf",
+		"fragments/c.conf":       "load constant 1000",
+		"fragments/ignored.frag": "load constant 5",
+		"nested/b.conf":          "load constant 20",
+		"cycle.conf":             "load constant 1\ninclude cycle_other.conf",
+		"cycle_other.conf":       "include cycle.conf",
+		"missing.conf":           "include nothing_here.conf\nload constant 1",
+		"empty_glob.conf":        "include nothing/*.conf\nload constant 7",
+		"broken.conf":            "include broken_fragment.conf\nload constant 1",
+		"broken_fragment.conf":   "load constant 1\ncheck nologn",
+		"budget.conf":            "include budget_fragment.conf\nload constant 1",
+		"budget_fragment.conf":   "timeout 1m",
+		"budget_main.conf":       "timeout 1m\ninclude common.conf",
+		"absolute_include.conf":  "",
+	})
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "absolute_include.conf"),
+		[]byte("include "+filepath.Join(dir, "common.conf")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	myTests := []struct {
+		file          string
+		expectedValue int
+		expectedError string
+	}{
+		{file: "lbclient.conf", expectedValue: 1131},
+		{file: "absolute_include.conf", expectedValue: 10},
+		{file: "empty_glob.conf", expectedValue: 7},
+		{file: "budget_main.conf", expectedValue: 10},
+		{file: "cycle.conf", expectedValue: -1, expectedError: "include cycle"},
+		{file: "missing.conf", expectedValue: -1, expectedError: "missing.conf:1:9: unable to include"},
+		{file: "broken.conf", expectedValue: -1, expectedError: "broken_fragment.conf:2:7: the check [nologn]"},
+		{file: "budget.conf", expectedValue: -1, expectedError: "budget_fragment.conf:1:1: the time budget can only"},
+	}
+	for _, myTest := range myTests {
+		t.Run(myTest.file, func(t *testing.T) {
+			cm := mapping.NewConfiguration(filepath.Join(dir, myTest.file), "test.cern.ch")
+			err := lbconfig.Evaluate(cm, defaultTimeout, true)
+			if myTest.expectedError == "" && err != nil {
+				t.Fatal(err)
+			}
+			if myTest.expectedError != "" && (err == nil || !strings.Contains(err.Error(), myTest.expectedError)) {
+				logger.Errorf("Expected the error [%s] but got [%v]", myTest.expectedError, err)
+				t.Fail()
+			}
+			if cm.MetricValue != myTest.expectedValue {
+				logger.Errorf("Expected the metric value [%d] but got [%d]", myTest.expectedValue, cm.MetricValue)
+				t.Fail()
+			}
+		})
+	}
+}
+
+// TestIncludedFragmentsAreNotAliases : the included files should not be evaluated as the configuration of an alias
+func TestIncludedFragmentsAreNotAliases(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createAliasesLauncher(t, map[string]string{
+		"a.cern.ch": "include lbclient.conf.base.cern.ch\nload constant 1",
+	})
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "lbclient.conf.base.cern.ch"), []byte("load constant 2"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if launcher.MetricValue != "3" || len(launcher.Mappings()) != 1 {
+		logger.Errorf("Expected the single output [3] but got [%s]", launcher.MetricValue)
+		t.Fail()
+	}
+}
@@ -0,0 +1,119 @@
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// TestLintRules : the linter should report the likely mistakes of a configuration file, on top of its errors
+func TestLintRules(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	myTests := []struct {
+		title    string
+		content  string
+		expected []string
+	}{
+		{title: "Clean", content: "check roger\ncheck command /bin/true\nload lemon [13163] * 2\nload constant 5"},
+		{title: "Deprecated", content: "check swaping\ncheck XSESSIONS",
+			expected: []string{"1:7: warning: the [swaping] check is deprecated and always succeeds [deprecated-check]",
+				"2:7: warning: the [xsessions] check is deprecated"}},
+		{title: "NegativeLoad", content: "load constant -3\nload lemon 100 - [13163]\nload collectd -[cpu/percent-idle]\n" +
+			"load collectd [load/load-relative:shortterm] * 100\nload constant 3",
+			expected: []string{"1:15: warning: the [constant] load can be negative, which excludes the node from the " +
+				"alias [negative-load]", "2:12: warning: the [lemon] load can be negative",
+				"3:15: warning: the [collectd] load can be negative"}},
+		{title: "Duplicate", content: "check nologin\ncheck  NOLOGIN\ncheck command /bin/true  -x\n" +
+			"check command /bin/true -x\nload constant 1\nload constant 1",
+			expected: []string{"2:1: warning: the check duplicates the one of the line [", "4:1: warning: the check " +
+				"duplicates the one of the line ["}},
+		{title: "DuplicateBranches", content: "if file_exists / {\n  check roger\n} else {\n  check roger\n}\n" +
+			"check roger",
+			expected: []string{"6:1: warning: the check duplicates the one of the line"}},
+		{title: "RelativeCommand", content: "check command ./probe.sh\ncheck command LANG=C scripts/probe\n" +
+			"check command true",
+			expected: []string{"1:15: warning: the command [./probe.sh] is relative to the working directory of the " +
+				"lbclient. Use an absolute path [relative-command]", "1:15: warning: the command [./probe.sh] cannot be run",
+				"2:15: warning: the command [scripts/probe] is relative", "2:15: warning: the command [scripts/probe] cannot"}},
+		{title: "Errors", content: "check blabla\nload constant x",
+			expected: []string{"1:7: error: the check [blabla] is not supported", "2:15: error: invalid arguments"}},
+	}
+
+	for _, myTest := range myTests {
+		t.Run(myTest.title, func(t *testing.T) {
+			dir := createFiles(t, map[string]string{"lbclient.conf": myTest.content})
+			defer os.RemoveAll(dir)
+			diagnostics := lbconfig.Lint(mapping.NewConfiguration(filepath.Join(dir, "lbclient.conf")))
+			if len(diagnostics) != len(myTest.expected) {
+				logger.Errorf("Expected [%d] diagnostics but got %v", len(myTest.expected), diagnostics)
+				t.FailNow()
+			}
+			for i, diagnostic := range diagnostics {
+				if !strings.Contains(diagnostic.String(), myTest.expected[i]) {
+					logger.Errorf("Expected the diagnostic [%s] but got [%s]", myTest.expected[i], diagnostic)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+// TestLintDirectory : the linter should cross-check the configuration files with the lbaliases and lbpost files, and
+// print the diagnostics as JSON
+func TestLintDirectory(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf":                "check roger\nload constant 1",
+		"lbclient.conf.a.cern.ch":      "check swaping\nload constant 2",
+		"lbclient.conf.orphan.cern.ch": "load constant 3",
+		"lbaliases":                    "lbalias=a.cern.ch\nlbalias=b.cern.ch\n",
+		"lbpost.yaml":                  "url: https://localhost\nstatus:\n  - aliasname: a.cern.ch\n    secret: c2VjcmV0\n",
+	})
+	defer os.RemoveAll(dir)
+
+	launcher := lbconfig.NewAppLauncher()
+	if err := launcher.ParseApplicationArguments([]string{"--cm", dir, "--ca", filepath.Join(dir, "lbaliases"),
+		"-p", filepath.Join(dir, "lbpost.yaml"), "lint", "--format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if launcher.AppOptions.Command != "lint" {
+		logger.Errorf("Expected the [lint] command but got [%s]", launcher.AppOptions.Command)
+		t.FailNow()
+	}
+	var out bytes.Buffer
+	if err := lbconfig.WriteDiagnostics(&out, launcher.Lint(), launcher.AppOptions.Lint.Format); err != nil {
+		t.Fatal(err)
+	}
+	var diagnostics []lbconfig.Diagnostic
+	if err := json.Unmarshal(out.Bytes(), &diagnostics); err != nil {
+		logger.Errorf("The output [%s] is not valid JSON: %v", out.String(), err)
+		t.FailNow()
+	}
+
+	expected := []lbconfig.Diagnostic{
+		{Severity: lbconfig.SeverityWarning, File: filepath.Join(dir, "lbaliases"), Line: 2, Column: 9,
+			Rule: lbconfig.RuleMissingSecret},
+		{Severity: lbconfig.SeverityWarning, File: filepath.Join(dir, "lbclient.conf.a.cern.ch"), Line: 1, Column: 7,
+			Rule: lbconfig.RuleDeprecatedCheck},
+		{Severity: lbconfig.SeverityWarning, File: filepath.Join(dir, "lbclient.conf.orphan.cern.ch"),
+			Rule: lbconfig.RuleUnlistedAlias},
+	}
+	if len(diagnostics) != len(expected) {
+		logger.Errorf("Expected [%d] diagnostics but got %v", len(expected), diagnostics)
+		t.FailNow()
+	}
+	for i, diagnostic := range diagnostics {
+		diagnostic.Message, diagnostic.Source = "", ""
+		if diagnostic != expected[i] {
+			logger.Errorf("Expected the diagnostic [%+v] but got [%+v]", expected[i], diagnostic)
+			t.Fail()
+		}
+	}
+}
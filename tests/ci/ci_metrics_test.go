@@ -0,0 +1,131 @@
+package ci
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/utils/metrics"
+)
+
+// TestMetricsTextFormat : the registry should follow the Prometheus text exposition format
+func TestMetricsTextFormat(t *testing.T) {
+	registry := metrics.NewRegistry()
+	gauge := registry.NewGauge("test_gauge", "A test gauge", "alias")
+	histogram := registry.NewHistogram("test_seconds", "A test histogram", []float64{0.5, 1}, "check")
+	gauge.Set(-14, `my"alias`)
+	histogram.Observe(0.25, "NOLOGIN")
+	histogram.Observe(0.75, "NOLOGIN")
+
+	var out bytes.Buffer
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP test_gauge A test gauge
+# TYPE test_gauge gauge
+test_gauge{alias="my\"alias"} -14
+# HELP test_seconds A test histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{check="NOLOGIN",le="0.5"} 1
+test_seconds_bucket{check="NOLOGIN",le="1"} 2
+test_seconds_bucket{check="NOLOGIN",le="+Inf"} 2
+test_seconds_sum{check="NOLOGIN"} 1
+test_seconds_count{check="NOLOGIN"} 2
+`
+	if out.String() != expected {
+		logger.Errorf("Expected the metrics [%s] but got [%s]", expected, out.String())
+		t.Fail()
+	}
+}
+
+// TestMetricsTextFile : the evaluation should export the per-alias and per-check metrics to the textfile
+func TestMetricsTextFile(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "load constant 5\ncheck command false")
+	defer os.RemoveAll(dir)
+	textFile, configuration := filepath.Join(dir, "lbclient.prom"), filepath.Join(dir, "lbclient.conf")
+	launcher.AppOptions.MetricsConfiguration.TextFile = textFile
+
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(textFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`lbclient_alias_load{alias="test.cern.ch"} -14`,
+		`lbclient_check_passed{alias="test.cern.ch",check="CONSTANT",line="` + configuration + `:1"} 1`,
+		`lbclient_check_passed{alias="test.cern.ch",check="COMMAND",line="` + configuration + `:2"} 0`,
+		`lbclient_check_duration_seconds_count{check="COMMAND"}`,
+		`lbclient_command_failures_total{command="command"}`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			logger.Errorf("Expected the metric [%s] in the textfile [%s]", expected, content)
+			t.Fail()
+		}
+	}
+}
+
+// TestMetricsSameKeyword : the lines with the same keyword should be exported separately, and the reused results should
+// not be observed in the durations
+func TestMetricsSameKeyword(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "penalize command false\ncheck command true\nload constant 5")
+	defer os.RemoveAll(dir)
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := metrics.Default.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	configuration := filepath.Join(dir, "lbclient.conf")
+	for _, expected := range []string{
+		`lbclient_check_passed{alias="test.cern.ch",check="COMMAND",line="` + configuration + `:1"} 0`,
+		`lbclient_check_passed{alias="test.cern.ch",check="COMMAND",line="` + configuration + `:2"} 1`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			logger.Errorf("Expected the metric [%s] in [%s]", expected, out.String())
+			t.Fail()
+		}
+	}
+
+	// durationCount : Returns the amount of durations observed for the command lines
+	durationCount := func() int {
+		var out bytes.Buffer
+		if err := metrics.Default.WriteText(&out); err != nil {
+			t.Fatal(err)
+		}
+		prefix := `lbclient_check_duration_seconds_count{check="COMMAND"} `
+		for _, line := range strings.Split(out.String(), "\n") {
+			if strings.HasPrefix(line, prefix) {
+				count, err := strconv.Atoi(strings.TrimPrefix(line, prefix))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return count
+			}
+		}
+		return 0
+	}
+	launcher, dir = createAliasesLauncher(t, map[string]string{
+		"a.cern.ch": "check command true\nload constant 1",
+		"b.cern.ch": "check command true\nload constant 2",
+		"c.cern.ch": "check command true\nload constant 3",
+	})
+	defer os.RemoveAll(dir)
+	before := durationCount()
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if observed := durationCount() - before; observed != 1 {
+		logger.Errorf("Expected a single duration for the command run once but got [%d]", observed)
+		t.Fail()
+	}
+}
@@ -0,0 +1,86 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+)
+
+// TestParser : the statements should keep their keyword, arguments and source position
+func TestParser(t *testing.T) {
+	src := "# Comment\n\n  check Collectd [load] < 10   timeout=5s\nload constant 5\ntimeout 1m\n"
+	file, err := config.Parse("lbclient.conf", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Statements) != 3 || len(file.Comments) != 1 {
+		t.Fatalf("Expected [3] statements and [1] comment but got [%d] and [%d]", len(file.Statements),
+			len(file.Comments))
+	}
+	action, ok := file.Statements[0].(*config.Action)
+	if !ok {
+		t.Fatalf("Expected an action but got [%T]", file.Statements[0])
+	}
+	if action.Kind != config.KindCheck || action.Keyword != "COLLECTD" || action.Args != "[load] < 10" ||
+		action.Timeout != 5*time.Second {
+		logger.Errorf("Unexpected action [%+v]", action)
+		t.Fail()
+	}
+	if action.Pos().String() != "lbclient.conf:3:3" || action.ArgsPos.String() != "lbclient.conf:3:18" {
+		logger.Errorf("Unexpected positions [%s] and [%s]", action.Pos(), action.ArgsPos)
+		t.Fail()
+	}
+	if file.Budget == nil || file.Budget.Duration != time.Minute {
+		logger.Errorf("Expected a budget of [1m] but got [%+v]", file.Budget)
+		t.Fail()
+	}
+}
+
+// TestParserErrors : the errors should point at the line and column of the problem
+func TestParserErrors(t *testing.T) {
+	file, err := config.Parse("lbclient.conf", []byte("load constant 5\nchek nologin\ncheck\ttmpfull timeout=x\n"))
+	if err == nil {
+		t.Fatal("Expected the parsing to fail")
+	}
+	errors, ok := err.(config.ErrorList)
+	if !ok || len(errors) != 2 {
+		t.Fatalf("Expected [2] errors but got [%v]", err)
+	}
+	expected := "lbclient.conf:2:1: unknown statement [chek]. Expected [check], [penalize], [load], [timeout], " +
+		"[aggregate], [include], [set] or [if]\n\tchek nologin\n\t^"
+	if errors[0].Error() != expected {
+		logger.Errorf("Expected the error [%s] but got [%s]", expected, errors[0])
+		t.Fail()
+	}
+	if !strings.HasPrefix(errors[1].Error(), "lbclient.conf:3:15: invalid timeout [x]") ||
+		!strings.HasSuffix(errors[1].Error(), "\n\t     \t        ^") {
+		logger.Errorf("Unexpected error [%s]", errors[1])
+		t.Fail()
+	}
+	// The lines that cannot be parsed keep their place
+	if _, ok := file.Statements[1].(*config.BadStatement); !ok || len(file.Statements) != 3 {
+		logger.Errorf("Expected the second statement to be a bad statement but got [%T]", file.Statements[1])
+		t.Fail()
+	}
+}
+
+// TestParsedArguments : the CLIs should receive the arguments of the parsed lines
+func TestParsedArguments(t *testing.T) {
+	myTests := []lbTest{
+		{title: "ExtraSpaces", configurationContent: "  CHECK   command   true\nload  constant  5",
+			expectedMetricValue: 5},
+		{title: "ConstantWithTooManyArguments", configurationContent: "load constant 5 6",
+			expectedMetricValue: -16, shouldFail: true},
+		{title: "CommandWithoutArguments", configurationContent: "check command\nload constant 5",
+			expectedMetricValue: -14, shouldFail: true},
+		{title: "UnsupportedLoad", configurationContent: "load nologin\nload constant 5",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "InvalidKeyword", configurationContent: "check lemon[_20003]>1\nload constant 5",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+	}
+
+	runMultipleTests(t, myTests)
+}
@@ -0,0 +1,63 @@
+package ci
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/snmp"
+)
+
+// createTestTable : creates a table with two aliases that are evaluated by two different configuration files
+func createTestTable(t *testing.T) *snmp.Table {
+	oid, err := snmp.ParseOID(".1.3.6.1.4.1.96.255.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := mapping.NewConfiguration("lbclient.conf.first.cern.ch", "first.cern.ch")
+	first.MetricValue = 5
+	second := mapping.NewConfiguration("lbclient.conf", "second.cern.ch")
+	second.MetricValue = -13
+	return snmp.NewTable(oid, "string", "first.cern.ch=5,second.cern.ch=-13",
+		[]*mapping.ConfigurationMapping{first, second})
+}
+
+func TestPassPersist(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	table := createTestTable(t)
+
+	myTests := []struct {
+		title, request, expected string
+	}{
+		{title: "Ping", request: "PING\n", expected: "PONG\n"},
+		{title: "GetBase", request: "get\n.1.3.6.1.4.1.96.255.1\n",
+			expected: ".1.3.6.1.4.1.96.255.1\nstring\nfirst.cern.ch=5,second.cern.ch=-13\n"},
+		{title: "GetAlias", request: "get\n.1.3.6.1.4.1.96.255.1.2\n",
+			expected: ".1.3.6.1.4.1.96.255.1.2\ninteger\n-13\n"},
+		{title: "GetMissing", request: "get\n.1.3.6.1.4.1.96.255.1.3\n", expected: "NONE\n"},
+		{title: "GetNextFromParent", request: "getnext\n.1.3.6.1.4.1.96\n",
+			expected: ".1.3.6.1.4.1.96.255.1\nstring\nfirst.cern.ch=5,second.cern.ch=-13\n"},
+		{title: "GetNextAlias", request: "getnext\n.1.3.6.1.4.1.96.255.1\n",
+			expected: ".1.3.6.1.4.1.96.255.1.1\ninteger\n5\n"},
+		{title: "GetNextEnd", request: "getnext\n.1.3.6.1.4.1.96.255.1.2\n", expected: "NONE\n"},
+		{title: "Set", request: "set\n.1.3.6.1.4.1.96.255.1\ninteger 3\n", expected: "not-writable\n"},
+		{title: "Session", request: "PING\nget\n.1.3.6.1.4.1.96.255.1.1\n\nPING\n",
+			expected: "PONG\n.1.3.6.1.4.1.96.255.1.1\ninteger\n5\n"},
+	}
+
+	for _, myTest := range myTests {
+		t.Run(myTest.title, func(t *testing.T) {
+			var out bytes.Buffer
+			err := snmp.ServePassPersist(strings.NewReader(myTest.request), &out, func() *snmp.Table { return table })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != myTest.expected {
+				logger.Errorf("Expected the answer [%q] but got [%q]", myTest.expected, out.String())
+				t.Fail()
+			}
+		})
+	}
+}
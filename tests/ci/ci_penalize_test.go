@@ -0,0 +1,83 @@
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// TestPenalize : the failing soft checks should add their penalty to the load instead of excluding the node
+func TestPenalize(t *testing.T) {
+	myTests := []lbTest{
+		{title: "Passing", configurationContent: "penalize command true\nload constant 5", expectedMetricValue: 5},
+		{title: "DefaultPenalty", configurationContent: "PENALIZE command false\nload constant 5",
+			expectedMetricValue: 105},
+		{title: "Penalty", configurationContent: "penalize command false PENALTY=250\nload constant 5",
+			expectedMetricValue: 255},
+		{title: "SeveralPenalties", configurationContent: "penalize command false penalty=10 timeout=5s\n" +
+			"penalize command true penalty=20\npenalize command false penalty=30\nload constant 5",
+			expectedMetricValue: 45},
+		{title: "PenaltyAfterAggregation", configurationContent: "aggregate max\npenalize command false penalty=20\n" +
+			"load constant 5\nload constant 7", expectedMetricValue: 27},
+		{title: "TimedOut", configurationContent: "penalize command sleep 2 timeout=100ms\nload constant 5",
+			expectedMetricValue: 105},
+		{title: "InsideBlock", configurationContent: "if hostname =~ /./ {\n  penalize command false\n}\n" +
+			"load constant 5", expectedMetricValue: 105},
+		{title: "HardCheckUnchanged", configurationContent: "penalize command false\ncheck command false\n" +
+			"load constant 5", expectedMetricValue: -14},
+		{title: "NotPenalizedWhenValidating", configurationContent: "penalize command false\nload constant 5",
+			expectedMetricValue: 5, validateConfig: true},
+		{title: "InvalidPenalty", configurationContent: "penalize command false penalty=0\nload constant 5",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "PenalizeConstant", configurationContent: "penalize constant 5\nload constant 5",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+	}
+
+	runMultipleTests(t, myTests)
+}
+
+// TestPenalizeExplain : the trace should show the penalized lines, and the penalties in the formula of the load
+func TestPenalizeExplain(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	configuration := "load constant 5\npenalize command false penalty=50\npenalize command true\n"
+	launcher, dir := createDaemonLauncher(t, configuration, "explain", "--format", "json")
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	if err := launcher.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	var reports []lbconfig.ExplainReport
+	if err := json.Unmarshal(out.Bytes(), &reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].MetricValue != 55 || reports[0].Formula != "5 + penalties(50) = 55" ||
+		len(reports[0].Lines) != 3 {
+		t.Fatalf("Unexpected reports [%+v]", reports)
+	}
+	penalized, passed := reports[0].Lines[1], reports[0].Lines[2]
+	if penalized.Kind != "penalize" || penalized.Penalty != 50 || penalized.Total != 55 || penalized.Excluded {
+		logger.Errorf("Unexpected trace of the penalized line [%+v]", penalized)
+		t.Fail()
+	}
+	if passed.Kind != "penalize" || passed.Penalty != 0 || passed.Total != 55 {
+		logger.Errorf("Unexpected trace of the passing line [%+v]", passed)
+		t.Fail()
+	}
+
+	launcher, dir = createDaemonLauncher(t, configuration, "explain")
+	defer os.RemoveAll(dir)
+	out.Reset()
+	if err := launcher.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "PENALIZED (+50)") {
+		logger.Errorf("Expected the penalized line in the table [%s]", out.String())
+		t.Fail()
+	}
+}
@@ -0,0 +1,102 @@
+package ci
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// TestResultCache : the identical lines of different configuration files should only run once per evaluation
+func TestResultCache(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	counter, err := ioutil.TempFile("/tmp", "lbclient_cache_counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = counter.Close()
+	defer os.Remove(counter.Name())
+
+	line := fmt.Sprintf("check command echo run >> %s", counter.Name())
+	launcher, dir := createAliasesLauncher(t, map[string]string{
+		"a.cern.ch": line + "\nload constant 1",
+		"b.cern.ch": "check command " + strings.Replace(strings.TrimPrefix(line, "check command "), " ", "  ", -1) +
+			"\nload constant 2",
+		"c.cern.ch": "CHECK COMMAND" + strings.TrimPrefix(line, "check command") + "\nload constant 3",
+	})
+	defer os.RemoveAll(dir)
+
+	for run := 1; run <= 2; run++ {
+		if err = launcher.Run(); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(counter.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The cache only lives for a single evaluation
+		if runs := strings.Count(string(content), "run"); runs != run {
+			logger.Errorf("Expected the command to run [%d] times but it ran [%d] times", run, runs)
+			t.Fail()
+		}
+	}
+	cached := 0
+	for _, cm := range launcher.Mappings() {
+		if cm.Results[0].Cached {
+			cached++
+		}
+	}
+	if cached != 2 {
+		logger.Errorf("Expected [2] results to be served from the cache but got [%d]", cached)
+		t.Fail()
+	}
+	if launcher.MetricValue != "a.cern.ch=1,b.cern.ch=2,c.cern.ch=3" {
+		logger.Errorf("Unexpected output [%s]", launcher.MetricValue)
+		t.Fail()
+	}
+}
+
+// TestResultCacheAliasDependent : the nologin check depends on the alias, so its result should not be shared
+func TestResultCacheAliasDependent(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createAliasesLauncher(t, map[string]string{
+		"a.cern.ch": "check nologin\nload constant 1",
+		"b.cern.ch": "check nologin\nload constant 2",
+	})
+	defer os.RemoveAll(dir)
+
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	for _, cm := range launcher.Mappings() {
+		if cm.Results[0].Cached {
+			logger.Errorf("The nologin result of the configuration file [%s] was served from the cache",
+				cm.ConfigFilePath)
+			t.Fail()
+		}
+	}
+}
+
+// TestResultCacheTimeout : the result of a line should not be shared with the same line run with another timeout
+func TestResultCacheTimeout(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createAliasesLauncher(t, map[string]string{
+		"a.cern.ch": "check command sleep 0.5 timeout=100ms\nload constant 1",
+		"b.cern.ch": "check command sleep 0.5\nload constant 2",
+	}, "--exec.workers", "1")
+	defer os.RemoveAll(dir)
+
+	// The timeout of the first alias is reported as an error
+	_ = launcher.Run()
+	expected := map[string]int{"a.cern.ch": -14, "b.cern.ch": 2}
+	for _, cm := range launcher.Mappings() {
+		if cm.MetricValue != expected[cm.AliasNames[0]] || cm.Results[0].Cached {
+			logger.Errorf("Expected the metric value [%d] for the alias [%s] but got [%d] (cached [%v])",
+				expected[cm.AliasNames[0]], cm.AliasNames[0], cm.MetricValue, cm.Results[0].Cached)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package ci
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// TestSlowStart : the load should be raised after the node returns to service, decaying linearly during the period
+func TestSlowStart(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir, err := ioutil.TempDir("/tmp", "lbclient_slowstart_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	uptime, roger, down := filepath.Join(dir, "uptime"), filepath.Join(dir, "roger.yaml"), filepath.Join(dir, "down")
+	write := func(path, content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(stateFile string) (int, string) {
+		launcher, configDir := createDaemonLauncher(t, "check command test ! -e "+down+"\nload constant 5\n",
+			"--state.file", filepath.Join(dir, stateFile), "--slowstart.period", "100s", "--slowstart.uptime", uptime,
+			"--slowstart.roger", roger)
+		defer os.RemoveAll(configDir)
+		if err := launcher.Run(); err != nil {
+			t.Fatal(err)
+		}
+		value, err := strconv.Atoi(launcher.MetricValue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value, launcher.Mappings()[0].Formula
+	}
+	// The load is raised by up to 1000 by default. The time spent by the test may lower it a bit
+	expect := func(title string, value, min, max int) {
+		if value < min || value > max {
+			logger.Errorf("%s: expected a load between [%d] and [%d] but got [%d]", title, min, max, value)
+			t.Fail()
+		}
+	}
+
+	write(uptime, "50.00 100.00\n")
+	value, formula := run("boot.json")
+	expect("Boot", value, 500, 505)
+	if !strings.HasPrefix(formula, "5 + slowstart(") {
+		logger.Errorf("Expected the slow-start in the formula [%s]", formula)
+		t.Fail()
+	}
+
+	write(uptime, "5000.00 10000.00\n")
+	value, _ = run("boot.json")
+	expect("AfterPeriod", value, 5, 5)
+
+	write(roger, "appstate: draining\n")
+	value, _ = run("roger.json")
+	expect("FirstRogerState", value, 5, 5)
+	write(roger, "appstate: production\n")
+	value, _ = run("roger.json")
+	expect("RogerProduction", value, 995, 1005)
+	os.Remove(roger)
+
+	write(down, "")
+	value, _ = run("failure.json")
+	expect("Excluded", value, -14, -14)
+	os.Remove(down)
+	value, _ = run("failure.json")
+	expect("FirstSuccess", value, 995, 1005)
+}
+
+// TestSlowStartDisabled : the load should not be raised when the slow-start is not enabled
+func TestSlowStartDisabled(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "load constant 5\n", "--state.file", "/tmp/lbclient_slowstart_disabled",
+		"--slowstart.uptime", "/dev/null")
+	defer os.RemoveAll(dir)
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if launcher.MetricValue != "5" {
+		logger.Errorf("Expected the output [5] but got [%s]", launcher.MetricValue)
+		t.Fail()
+	}
+	if _, err := os.Stat("/tmp/lbclient_slowstart_disabled"); !os.IsNotExist(err) {
+		logger.Error("No state file should be written without slow-start")
+		t.Fail()
+	}
+}
+
+// TestSlowStartInvalidOptions : the negative period and the non-positive extra load should be rejected with the
+// arguments
+func TestSlowStartInvalidOptions(t *testing.T) {
+	for _, args := range [][]string{
+		{"--slowstart.period", "-10m"},
+		{"--slowstart.period", "10m", "--slowstart.extra", "0"},
+		{"--slowstart.period", "10m", "--slowstart.extra", "-500"},
+	} {
+		launcher := lbconfig.NewAppLauncher()
+		if err := launcher.ParseApplicationArguments(args); err == nil {
+			logger.Errorf("Expected the arguments %v to be rejected", args)
+			t.Fail()
+		}
+	}
+}
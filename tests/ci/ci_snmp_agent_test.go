@@ -0,0 +1,146 @@
+package ci
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/snmp"
+)
+
+// snmpClient : minimal SNMP manager used to query the agent over the loopback interface
+type snmpClient struct {
+	t    *testing.T
+	conn net.Conn
+}
+
+// newSNMPClient : starts an agent on a random loopback port and connects a client to it
+func newSNMPClient(t *testing.T, agent *snmp.Agent) (*snmpClient, func()) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = agent.Serve(server) }()
+
+	conn, err := net.Dial("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &snmpClient{t: t, conn: conn}, func() {
+		_ = conn.Close()
+		_ = server.Close()
+	}
+}
+
+// exchange : sends a message and returns the decoded answer, or nil if the agent did not answer
+func (c *snmpClient) exchange(message *snmp.Message, user *snmp.USMUser) *snmp.Message {
+	data, err := message.Marshal(user)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err = c.conn.Write(data); err != nil {
+		c.t.Fatal(err)
+	}
+	_ = c.conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	buffer := make([]byte, 65535)
+	n, err := c.conn.Read(buffer)
+	if err != nil {
+		return nil
+	}
+	response, err := snmp.UnmarshalMessage(buffer[:n])
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return response
+}
+
+func TestSNMPAgentCommunity(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	table := createTestTable(t)
+	agent := snmp.NewAgent(func() *snmp.Table { return table })
+	agent.Communities = []string{"lbclient"}
+	client, closeClient := newSNMPClient(t, agent)
+	defer closeClient()
+
+	base, _ := snmp.ParseOID(".1.3.6.1.4.1.96.255.1")
+	alias, _ := snmp.ParseOID(".1.3.6.1.4.1.96.255.1.2")
+
+	response := client.exchange(&snmp.Message{Version: snmp.Version2c, Community: "lbclient", PDU: snmp.PDU{
+		Type: snmp.GetRequestPDU, RequestID: 7, VarBinds: []snmp.VarBind{{Name: base, Type: snmp.BERNull}}}}, nil)
+	if response == nil || response.PDU.RequestID != 7 || len(response.PDU.VarBinds) != 1 ||
+		response.PDU.VarBinds[0].Value != "first.cern.ch=5,second.cern.ch=-13" {
+		logger.Errorf("Unexpected answer to the get request [%+v]", response)
+		t.Fail()
+	}
+
+	response = client.exchange(&snmp.Message{Version: snmp.Version2c, Community: "lbclient", PDU: snmp.PDU{
+		Type: snmp.GetNextRequestPDU, RequestID: 8, VarBinds: []snmp.VarBind{{Name: base.Append(1), Type: snmp.BERNull},
+			{Name: alias, Type: snmp.BERNull}}}}, nil)
+	if response == nil || len(response.PDU.VarBinds) != 2 ||
+		response.PDU.VarBinds[0].Name.Compare(alias) != 0 || response.PDU.VarBinds[0].Value != int64(-13) ||
+		response.PDU.VarBinds[1].Type != snmp.BEREndOfMibView {
+		logger.Errorf("Unexpected answer to the getnext request [%+v]", response)
+		t.Fail()
+	}
+
+	response = client.exchange(&snmp.Message{Version: snmp.Version2c, Community: "public", PDU: snmp.PDU{
+		Type: snmp.GetRequestPDU, RequestID: 9, VarBinds: []snmp.VarBind{{Name: base, Type: snmp.BERNull}}}}, nil)
+	if response != nil {
+		logger.Errorf("The agent answered a request with an unknown community [%+v]", response)
+		t.Fail()
+	}
+}
+
+func TestSNMPAgentUSM(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	table := createTestTable(t)
+	agent := snmp.NewAgent(func() *snmp.Table { return table })
+	if err := agent.AddUser("lbuser:SHA:lbpassword"); err != nil {
+		t.Fatal(err)
+	}
+	client, closeClient := newSNMPClient(t, agent)
+	defer closeClient()
+	alias, _ := snmp.ParseOID(".1.3.6.1.4.1.96.255.1.1")
+	request := func(user *snmp.USMUser, engineID string, boots, engineTime int64) *snmp.Message {
+		message := &snmp.Message{Version: snmp.Version3, MsgID: 1, MaxSize: 65507,
+			Flags: snmp.FlagReportable, SecurityModel: snmp.SecurityModelUSM, ContextEngineID: engineID,
+			Security: snmp.USMParameters{EngineID: engineID, EngineBoots: boots, EngineTime: engineTime},
+			PDU: snmp.PDU{Type: snmp.GetRequestPDU, RequestID: 3,
+				VarBinds: []snmp.VarBind{{Name: alias, Type: snmp.BERNull}}}}
+		if user != nil {
+			message.Flags |= snmp.FlagAuth
+			message.Security.UserName = user.Name
+		}
+		return client.exchange(message, user)
+	}
+
+	// Engine ID discovery
+	discovery := request(nil, "", 0, 0)
+	if discovery == nil || discovery.PDU.Type != snmp.ReportPDU || len(discovery.Security.EngineID) == 0 {
+		t.Fatalf("Unexpected answer to the discovery request [%+v]", discovery)
+	}
+	engine := discovery.Security
+
+	user, _ := snmp.ParseUSMUser("lbuser:SHA:lbpassword")
+	response := request(user, engine.EngineID, engine.EngineBoots, engine.EngineTime)
+	if response == nil || response.PDU.Type != snmp.ResponsePDU || !response.Verify(user) ||
+		len(response.PDU.VarBinds) != 1 || response.PDU.VarBinds[0].Value != int64(5) {
+		logger.Errorf("Unexpected answer to the authenticated get request [%+v]", response)
+		t.Fail()
+	}
+
+	wrongUser, _ := snmp.ParseUSMUser("lbuser:SHA:wrongpassword")
+	response = request(wrongUser, engine.EngineID, engine.EngineBoots, engine.EngineTime)
+	if response == nil || response.PDU.Type != snmp.ReportPDU || response.Flags&snmp.FlagAuth != 0 {
+		logger.Errorf("Expected a report for the request with a wrong password but got [%+v]", response)
+		t.Fail()
+	}
+
+	response = request(user, engine.EngineID, engine.EngineBoots, engine.EngineTime+1000)
+	if response == nil || response.PDU.Type != snmp.ReportPDU || !response.Verify(user) {
+		logger.Errorf("Expected an authenticated report for the request out of the time window but got [%+v]",
+			response)
+		t.Fail()
+	}
+}
@@ -0,0 +1,216 @@
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// TestStateDampening : a check should only exclude the node after the consecutive failures, and only include it again
+// after the consecutive successes, with the state kept between the runs
+func TestStateDampening(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir, err := ioutil.TempDir("/tmp", "lbclient_state_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	down, stateFile := filepath.Join(dir, "down"), filepath.Join(dir, "state", "state.json")
+	configuration := "check command test ! -e " + down + "\nload constant 5\n"
+
+	for i, step := range []struct {
+		down     bool
+		expected string
+	}{
+		{down: false, expected: "5"},
+		{down: true, expected: "5"},
+		{down: false, expected: "5"},
+		{down: true, expected: "5"},
+		{down: true, expected: "-14"},
+		{down: true, expected: "-14"},
+		{down: false, expected: "-14"},
+		{down: false, expected: "5"},
+	} {
+		if step.down {
+			if err = ioutil.WriteFile(down, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			os.Remove(down)
+		}
+		launcher, configDir := createDaemonLauncher(t, configuration, "--state.file", stateFile,
+			"--state.failures", "2", "--state.successes", "2")
+		if err = launcher.Run(); err != nil {
+			t.Fatal(err)
+		}
+		os.RemoveAll(configDir)
+		if launcher.MetricValue != step.expected {
+			logger.Errorf("Expected the output [%s] at the run [%d] but got [%s]", step.expected, i,
+				launcher.MetricValue)
+			t.Fail()
+		}
+	}
+
+	state, err := lbconfig.ReadState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := state.Aliases["test.cern.ch"]["check command test ! -e "+down]
+	if check == nil || check.Failing || check.Successes != 2 || check.Failures != 0 {
+		logger.Errorf("Unexpected state [%+v]", state.Aliases)
+		t.Fail()
+	}
+}
+
+// TestStatePrune : the state of the lines removed from the configuration file should be dropped at the next run
+func TestStatePrune(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir, err := ioutil.TempDir("/tmp", "lbclient_state_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+
+	for _, configuration := range []string{
+		"check command true\ncheck command false\nload constant 5\n",
+		"check command true\nload constant 5\n",
+	} {
+		launcher, configDir := createDaemonLauncher(t, configuration, "--state.file", stateFile,
+			"--state.failures", "2")
+		if err = launcher.Run(); err != nil {
+			t.Fatal(err)
+		}
+		os.RemoveAll(configDir)
+	}
+
+	state, err := lbconfig.ReadState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := state.Aliases["test.cern.ch"]
+	if len(checks) != 1 || checks["check command true"] == nil {
+		logger.Errorf("Expected only the state of the remaining line but got [%+v]", state.Aliases)
+		t.Fail()
+	}
+}
+
+// TestStateDisabled : without dampening rules, the checks should act at once and no state file should be written
+func TestStateDisabled(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "check command false\nload constant 5\n", "--state.file",
+		"/tmp/lbclient_state_disabled/state.json")
+	defer os.RemoveAll(dir)
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if launcher.MetricValue != "-14" {
+		logger.Errorf("Expected the output [-14] but got [%s]", launcher.MetricValue)
+		t.Fail()
+	}
+	if _, err := os.Stat("/tmp/lbclient_state_disabled"); !os.IsNotExist(err) {
+		logger.Error("No state file should be written without dampening")
+		t.Fail()
+	}
+}
+
+// TestStateCommand : the state command should show the state of all the aliases or of one, and reset it
+func TestStateCommand(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir, err := ioutil.TempDir("/tmp", "lbclient_state_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+	state := lbconfig.NewState()
+	state.Aliases["a.cern.ch"] = map[string]*lbconfig.CheckState{"check roger": {Failures: 3, Failing: true}}
+	state.Aliases["b.cern.ch"] = map[string]*lbconfig.CheckState{"check nologin": {Successes: 1}}
+	state.Aliases["c.cern.ch,d.cern.ch"] = map[string]*lbconfig.CheckState{"check reboot": {Failures: 1}}
+	if err = state.Write(stateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	runState := func(args ...string) (string, error) {
+		launcher := lbconfig.NewAppLauncher()
+		if err := launcher.ParseApplicationArguments(append([]string{"--state.file", stateFile, "state"},
+			args...)); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		err := launcher.State(&out)
+		return out.String(), err
+	}
+	run := func(args ...string) string {
+		out, err := runState(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	table := run()
+	if !strings.Contains(table, "a.cern.ch") || !strings.Contains(table, "FAILING") ||
+		!strings.Contains(table, "b.cern.ch") {
+		logger.Errorf("Unexpected state table [%s]", table)
+		t.Fail()
+	}
+	var selected lbconfig.State
+	if err = json.Unmarshal([]byte(run("--alias", "a.cern.ch", "--format", "json")), &selected); err != nil {
+		t.Fatal(err)
+	}
+	if len(selected.Aliases) != 1 || selected.Aliases["a.cern.ch"]["check roger"].Failures != 3 {
+		logger.Errorf("Unexpected state of the alias [%+v]", selected.Aliases)
+		t.Fail()
+	}
+
+	// The aliases sharing the default configuration file share their state
+	if shared := run("--alias", "d.cern.ch"); !strings.Contains(shared, "c.cern.ch,d.cern.ch") ||
+		strings.Contains(shared, "a.cern.ch") {
+		logger.Errorf("Unexpected state table of the shared alias [%s]", shared)
+		t.Fail()
+	}
+	for _, args := range [][]string{{"--alias", "e.cern.ch"}, {"--reset", "--alias", "e.cern.ch"}} {
+		if _, err = runState(args...); err == nil {
+			logger.Errorf("Expected an error for the unknown alias with %v", args)
+			t.Fail()
+		}
+	}
+
+	run("--reset", "--alias", "a.cern.ch")
+	run("--reset", "--alias", "c.cern.ch")
+	if state, err = lbconfig.ReadState(stateFile); err != nil || len(state.Aliases) != 1 ||
+		state.Aliases["b.cern.ch"] == nil {
+		logger.Errorf("Expected only the state of [b.cern.ch] but got [%+v]", state)
+		t.Fail()
+	}
+	run("--reset")
+	if state, err = lbconfig.ReadState(stateFile); err != nil || len(state.Aliases) != 0 {
+		logger.Errorf("Expected an empty state but got [%+v]", state)
+		t.Fail()
+	}
+}
+
+// TestStateInvalidThresholds : the amounts of consecutive failures and successes below 1 should be rejected
+func TestStateInvalidThresholds(t *testing.T) {
+	for _, args := range [][]string{
+		{"--state.failures", "0"},
+		{"--state.failures", "-2"},
+		{"--state.successes", "0"},
+		{"--state.successes", "-1"},
+	} {
+		launcher := lbconfig.NewAppLauncher()
+		err := launcher.ParseApplicationArguments(args)
+		if err == nil || !strings.Contains(err.Error(), "must be at least 1") {
+			logger.Errorf("Expected the arguments %v to be rejected but got the error [%v]", args, err)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package ci
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// getStatus : queries the status API and decodes the JSON answer into the given value
+func getStatus(t *testing.T, server *httptest.Server, path string, value interface{}) int {
+	response, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if err = json.NewDecoder(response.Body).Decode(value); err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode
+}
+
+// TestStatusAPI : the status API should serve the per-line results of the cached evaluation
+func TestStatusAPI(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createDaemonLauncher(t, "load constant 5\ncheck command false\nload constant 7")
+	defer os.RemoveAll(dir)
+
+	daemon := lbconfig.NewDaemon(launcher)
+	server := httptest.NewServer(lbconfig.NewStatusHandler(daemon))
+	defer server.Close()
+
+	// Nothing was evaluated yet
+	var failure map[string]string
+	if code := getStatus(t, server, "/status", &failure); code != http.StatusServiceUnavailable {
+		logger.Errorf("Expected the status code [%d] before the first evaluation but got [%d]",
+			http.StatusServiceUnavailable, code)
+		t.Fail()
+	}
+
+	daemon.Refresh()
+	var status lbconfig.Status
+	if code := getStatus(t, server, "/status", &status); code != http.StatusOK {
+		t.Fatalf("Expected the status code [%d] but got [%d]", http.StatusOK, code)
+	}
+	if status.MetricValue != "-14" || len(status.Aliases) != 1 {
+		t.Fatalf("Unexpected status [%+v]", status)
+	}
+	alias := status.Aliases[0]
+	if alias.Alias != "test.cern.ch" || alias.MetricValue != -14 || alias.FailureCode != 14 ||
+		len(alias.Lines) != 2 {
+		t.Fatalf("Unexpected alias status [%+v]", alias)
+	}
+	if alias.Lines[0].Kind != "load" || alias.Lines[0].Result != 5 ||
+		alias.Lines[1].Action != "COMMAND" || alias.Lines[1].Kind != "check" || alias.Lines[1].Result != -1 {
+		logger.Errorf("Unexpected line results [%+v]", alias.Lines)
+		t.Fail()
+	}
+
+	var single lbconfig.AliasStatus
+	if code := getStatus(t, server, "/status/test.cern.ch", &single); code != http.StatusOK ||
+		single.Alias != "test.cern.ch" || single.ConfigFile != alias.ConfigFile {
+		logger.Errorf("Unexpected answer [%d] for a single alias [%+v]", code, single)
+		t.Fail()
+	}
+	if code := getStatus(t, server, "/status/unknown.cern.ch", &failure); code != http.StatusNotFound {
+		logger.Errorf("Expected the status code [%d] for an unknown alias but got [%d]", http.StatusNotFound, code)
+		t.Fail()
+	}
+}
+
+// TestStatusListen : the status API should only listen on the loopback interface or on a unix socket
+func TestStatusListen(t *testing.T) {
+	for _, address := range []string{":0", "0.0.0.0:0", "[::]:0"} {
+		if listener, err := lbconfig.ListenStatus(address); err == nil {
+			listener.Close()
+			logger.Errorf("Expected the address [%s] to be refused", address)
+			t.Fail()
+		}
+	}
+	for _, address := range []string{"127.0.0.1:0", "localhost:0"} {
+		listener, err := lbconfig.ListenStatus(address)
+		if err != nil {
+			logger.Errorf("Expected the address [%s] to be accepted but got [%v]", address, err)
+			t.Fail()
+			continue
+		}
+		listener.Close()
+	}
+}
@@ -0,0 +1,200 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/config"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// TestStructuredFormat : the YAML and JSON configuration files should be evaluated like their line format equivalent
+func TestStructuredFormat(t *testing.T) {
+	dir := createFiles(t, map[string]string{
+		"loads.yaml": "timeout: 1m\nvariables:\n  - name: BASE\n    value: 5\nchecks:\n  - name: always\n" +
+			"    type: command\n    args: /bin/true\n    timeout: 5s\nloads:\n  - type: constant\n    args: ${BASE}\n" +
+			"  - type: constant\n    args: \"2\"\n",
+		"when.yaml": "loads:\n  - type: constant\n    args: 1\n    when: [\"file_exists /\"]\n  - type: constant\n" +
+			"    args: 10\n    when: [\"!file_exists /\"]\n  - type: constant\n    args: 100\n" +
+			"    when: [\"file_exists /\", \"hostname =~ /./\"]\n",
+		"failing.json": "{\n  \"checks\": [\n    {\"type\": \"command\", \"args\": \"/bin/false\"}\n  ],\n" +
+			"  \"loads\": [\n    {\"type\": \"constant\", \"args\": \"4\"}\n  ]\n}\n",
+		"included.yaml": "include:\n  - common.conf\nloads:\n  - type: constant\n    args: 1\n",
+		"common.conf":   "load constant 6",
+		"unknown.yaml":  "checks:\n  - type: roger\n    argz: x\n",
+		"penalty.yaml": "penalties:\n  - type: command\n    args: /bin/false\n    penalty: 50\n" +
+			"  - type: command\n    args: /bin/false\nloads:\n  - type: constant\n    args: 5\n",
+		"weighted.yaml": "aggregate: weighted\nloads:\n  - type: constant\n    args: 10\n    weight: 0.7\n" +
+			"  - type: constant\n    args: 30\n    weight: 0.3\n",
+	})
+	defer os.RemoveAll(dir)
+
+	myTests := []lbTest{
+		{title: "Loads", configuration: filepath.Join(dir, "loads.yaml"), expectedMetricValue: 7},
+		{title: "When", configuration: filepath.Join(dir, "when.yaml"), expectedMetricValue: 101},
+		{title: "FailingJSON", configuration: filepath.Join(dir, "failing.json"), expectedMetricValue: -14},
+		{title: "Include", configuration: filepath.Join(dir, "included.yaml"), expectedMetricValue: 7},
+		{title: "Penalties", configuration: filepath.Join(dir, "penalty.yaml"), expectedMetricValue: 155},
+		{title: "Weighted", configuration: filepath.Join(dir, "weighted.yaml"), expectedMetricValue: 16},
+		{title: "UnknownKey", configuration: filepath.Join(dir, "unknown.yaml"), expectedMetricValue: 0,
+			shouldFail: true},
+	}
+
+	runMultipleTests(t, myTests)
+}
+
+// TestStructuredPositions : the errors of the structured files should point at the lines of their elements
+func TestStructuredPositions(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.yaml": "# The checks\nchecks:\n  - name: web\n    type: daemon\n    params:\n      port: 99999\n" +
+			"  - type: blabla\nloads:\n  - type: constant\n    args: x\n    when: [\"alias =~ /(/\"]\n",
+		"lbclient.json": "{\n  \"loads\": [\n    {\"type\": \"constant\", \"args\": \"4\"},\n" +
+			"    {\"type\": \"constant\", \"args\": \"y\"}\n  ]\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	expected := map[string][]string{
+		"lbclient.yaml": {"lbclient.yaml:3:14: error: invalid arguments for the [daemon] check",
+			"lbclient.yaml:7:7: error: the check [blabla] is not supported",
+			"lbclient.yaml:9:13: error: invalid regular expression"},
+		"lbclient.json": {"lbclient.json:4:15: error: invalid arguments for the [constant] load"},
+	}
+	for name, messages := range expected {
+		diagnostics := lbconfig.Validate(mapping.NewConfiguration(filepath.Join(dir, name)))
+		if len(diagnostics) != len(messages) {
+			logger.Errorf("Expected [%d] diagnostics but got %v", len(messages), diagnostics)
+			t.Fail()
+			continue
+		}
+		for i, diagnostic := range diagnostics {
+			if !strings.Contains(diagnostic.String(), messages[i]) {
+				logger.Errorf("Expected the diagnostic [%s] but got [%s]", messages[i], diagnostic)
+				t.Fail()
+			}
+		}
+	}
+}
+
+// TestStructuredMultiline : the names and the conditions spanning several lines should be rejected instead of
+// shifting the lines of the rendered file
+func TestStructuredMultiline(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	for _, content := range []string{
+		"checks: [{name: \"a\\nb\", type: nologin}]\n",
+		"checks: [{type: nologin, when: [\"file_exists /\\n\"]}]\nloads: [{type: constant, args: x}]\n",
+		"variables: [{name: A, value: 1, when: [\"file_exists /\\r\"]}]\n",
+	} {
+		_, err := config.ParseStructured("lbclient.yaml", []byte(content))
+		if err == nil || !strings.Contains(err.Error(), "cannot span several lines") {
+			logger.Errorf("Expected the file [%s] to be rejected but got the error [%v]", content, err)
+			t.Fail()
+		}
+	}
+}
+
+// TestConvert : the conversion should translate the files both ways, keeping their meaning
+func TestConvert(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf": "timeout 1m\naggregate max\nset BASE = 3\n# ssh daemon\n" +
+			"check daemon {\"port\": 22, \"protocol\": \"tcp\"}\ncheck nologin timeout=5s\n" +
+			"penalize command /bin/false penalty=20\nif file_exists /nonexistent {\n  load constant 1\n" +
+			"} else if hostname =~ /./ {\n  load constant ${BASE}\n}\ncheck collectd_alarms [{\"a\": \"okay\"}]\n" +
+			"load constant 4\n",
+		"block.conf": "if alias == a {\n  include common.conf\n}\n",
+		"late.conf":  "load constant ${A}\nset A = 1\n",
+	})
+	defer os.RemoveAll(dir)
+
+	yaml, err := config.Convert(filepath.Join(dir, "lbclient.conf"), config.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"timeout: 1m\naggregate: max\n", "- name: BASE\n  value: \"3\"\n",
+		"- name: ssh daemon\n  type: daemon\n  params:\n    port: 22\n    protocol: tcp\n", "- type: nologin\n  timeout: 5s\n",
+		"  - '!file_exists /nonexistent'\n  - hostname =~ /./\n", "  params:\n  - a: okay\n",
+		"penalties:\n- type: command\n  args: /bin/false\n  penalty: \"20\"\n"} {
+		if !strings.Contains(string(yaml), expected) {
+			logger.Errorf("Expected [%s] in the converted file [%s]", expected, yaml)
+			t.Fail()
+		}
+	}
+
+	// Back to the line format, through JSON
+	jsonFile := filepath.Join(dir, "lbclient.json")
+	launcher := lbconfig.NewAppLauncher()
+	if err = launcher.ParseApplicationArguments([]string{"convert", "--format", "json", "-o", jsonFile,
+		filepath.Join(dir, "lbclient.conf")}); err != nil {
+		t.Fatal(err)
+	}
+	if err = launcher.Convert(os.Stdout); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := config.Convert(jsonFile, config.FormatConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "timeout 1m\naggregate max\nset BASE = 3\n# ssh daemon\n" +
+		"check daemon {\"port\": 22, \"protocol\": \"tcp\"}\ncheck nologin timeout=5s\n" +
+		"check collectd_alarms [{\"a\": \"okay\"}]\npenalize command /bin/false penalty=20\n" +
+		"if file_exists /nonexistent {\n  load constant 1\n}\nif !file_exists /nonexistent {\n" +
+		"  if hostname =~ /./ {\n    load constant ${BASE}\n  }\n}\nload constant 4\n"
+	if string(conf) != expected {
+		logger.Errorf("Expected the converted file [%s] but got [%s]", expected, conf)
+		t.Fail()
+	}
+
+	for _, name := range []string{"block.conf", "late.conf"} {
+		if _, err = config.Convert(filepath.Join(dir, name), config.FormatYAML); err == nil {
+			logger.Errorf("The file [%s] should not be convertible", name)
+			t.Fail()
+		}
+	}
+}
+
+// TestStructuredAliases : the structured files should be found in the configuration directory like the other ones
+func TestStructuredAliases(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.yaml":           "loads:\n  - type: constant\n    args: 1\n",
+		"lbclient.a.cern.ch.json": "{\"loads\": [{\"type\": \"constant\", \"args\": \"2\"}]}",
+		"lbclient.conf.b.cern.ch": "load constant 3",
+		"lbaliases":               "lbalias=a.cern.ch\nlbalias=b.cern.ch\nlbalias=c.cern.ch\n",
+	})
+	defer os.RemoveAll(dir)
+	conflict := createFiles(t, map[string]string{
+		"lbclient.conf": "load constant 1",
+		"lbclient.json": "{}",
+		"lbaliases":     "lbalias=c.cern.ch\n",
+	})
+	defer os.RemoveAll(conflict)
+
+	launcher := lbconfig.NewAppLauncher()
+	if err := launcher.ParseApplicationArguments([]string{"--cm", dir, "--ca",
+		filepath.Join(dir, "lbaliases")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a.cern.ch=2,b.cern.ch=3,c.cern.ch=1"
+	if launcher.MetricValue != expected {
+		logger.Errorf("Expected the output [%s] but got [%s]", expected, launcher.MetricValue)
+		t.Fail()
+	}
+
+	launcher = lbconfig.NewAppLauncher()
+	if err := launcher.ParseApplicationArguments([]string{"--cm", conflict, "--ca",
+		filepath.Join(conflict, "lbaliases")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := launcher.Run(); err == nil {
+		logger.Error("Expected an error for the two default configuration files")
+		t.Fail()
+	}
+}
@@ -13,3 +13,34 @@ func TestTimeOut(t *testing.T) {
 
 	runMultipleTests(t, myTests)
 }
+
+func TestLineTimeOut(t *testing.T) {
+	myTests := []lbTest{
+		{title: "LineTimeoutKillExecution",
+			configurationContent: "check command sleep 2 timeout=500ms\nload constant 5",
+			expectedMetricValue:  -14, shouldFail: true},
+		{title: "LineTimeoutOverridesGlobal",
+			configurationContent: "check command sleep 1 TIMEOUT=3s\nload constant 5",
+			expectedMetricValue:  5, timeout: 500 * time.Millisecond},
+		{title: "LineTimeoutOnlyAppliesToItsLine",
+			configurationContent: "check command sleep 1\ncheck command true timeout=100ms\nload constant 5",
+			expectedMetricValue:  5},
+		{title: "BudgetExhausted",
+			configurationContent: "timeout 1s\ncheck command sleep 0.6\ncheck command sleep 0.6\nload constant 5",
+			expectedMetricValue:  -14, shouldFail: true},
+		{title: "BudgetNotExhausted",
+			configurationContent: "check command sleep 0.2\nload constant 5\nTIMEOUT 10s",
+			expectedMetricValue:  5},
+		{title: "InvalidLineTimeout",
+			configurationContent: "check command true timeout=5\nload constant 5",
+			expectedMetricValue:  -1, shouldFail: true, validateConfig: true},
+		{title: "InvalidBudget",
+			configurationContent: "timeout soon\nload constant 5",
+			expectedMetricValue:  -1, shouldFail: true, validateConfig: true},
+		{title: "DuplicatedBudget",
+			configurationContent: "timeout 1m\ntimeout 2m\nload constant 5",
+			expectedMetricValue:  -1, shouldFail: true, validateConfig: true},
+	}
+
+	runMultipleTests(t, myTests)
+}
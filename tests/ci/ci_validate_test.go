@@ -0,0 +1,108 @@
+package ci
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig/mapping"
+)
+
+// validate : validates the given configuration content
+func validate(t *testing.T, content string) []lbconfig.Diagnostic {
+	file, err := ioutil.TempFile("/tmp", "lbclient_validate_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err = file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return lbconfig.Validate(mapping.NewConfiguration(file.Name()))
+}
+
+// TestValidate : the static validation should report the problems of every line, without running anything
+func TestValidate(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	myTests := []struct {
+		title    string
+		content  string
+		expected []string
+	}{
+		{title: "Valid", content: "check roger\ncheck nologin\ncheck command true\nset P = 22\n" +
+			"check daemon {\"port\": ${P}}\ncheck lemon _20002 > 5\ncheck collectd_alarms [{\"a\": \"okay\"}]\n" +
+			"load collectd [load/load-relative:shortterm] * 100\nload constant 5"},
+		{title: "Grammar", content: "chek roger\ncheck blabla\ncheck constant 4\nload nologin",
+			expected: []string{"1:1: error: unknown statement", "2:7: error: the check [blabla] is not supported",
+				"3:7: error: a constant can only be used", "4:6: error: the [nologin] CLI cannot be used"}},
+		{title: "Arguments", content: "load constant five\ncheck lemon 5 + \nload collectd 4 + [dasdas \n" +
+			"check daemon {\"port\": 99999}\ncheck daemon {\"protocol\": \"tcp\"}\ncheck collectd_alarms [{\"a\": 1}]\n" +
+			"check command",
+			expected: []string{"1:15: error: invalid arguments for the [constant] load",
+				"2:13: error: invalid arguments for the [lemon] check", "3:15: error: invalid arguments",
+				"4:14: error: invalid arguments", "5:14: error: invalid arguments for the [daemon] check: a port",
+				"6:23: error: invalid arguments", "7:15: error: invalid arguments for the [command] check"}},
+		{title: "Warnings", content: "check command /nonexistent/probe --fast\ncheck nologin please",
+			expected: []string{"1:15: warning: the command [/nonexistent/probe] cannot be run",
+				"2:15: warning: the arguments are ignored by the [nologin] check"}},
+		{title: "Variables", content: "load constant ${UNDEFINED_PROBE}\nif UNDEFINED_ROLE == web {\n" +
+			"  set X = 1\n} else {\n  load constant ${X}\n}\nload constant ${X}",
+			expected: []string{"1:15: error: the variable [UNDEFINED_PROBE] is not defined",
+				"2:4: error: the variable [UNDEFINED_ROLE] is not defined"}},
+		{title: "BothBranches", content: "if file_exists / {\n  load constant 1\n} else {\n  load constant x\n}",
+			expected: []string{"4:17: error: invalid arguments"}},
+	}
+
+	for _, myTest := range myTests {
+		t.Run(myTest.title, func(t *testing.T) {
+			diagnostics := validate(t, myTest.content)
+			if len(diagnostics) != len(myTest.expected) {
+				logger.Errorf("Expected [%d] diagnostics but got %v", len(myTest.expected), diagnostics)
+				t.FailNow()
+			}
+			for i, diagnostic := range diagnostics {
+				if !strings.Contains(diagnostic.String(), myTest.expected[i]) {
+					logger.Errorf("Expected the diagnostic [%s] but got [%s]", myTest.expected[i], diagnostic)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+// TestValidateHasNoSideEffects : the validation should not run the checks
+func TestValidateHasNoSideEffects(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir, err := ioutil.TempDir("/tmp", "lbclient_validate_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "marker")
+	configuration := filepath.Join(dir, "lbclient.conf")
+	if err = ioutil.WriteFile(configuration, []byte("check command touch "+marker+"\nload constant 1"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+
+	launcher := lbconfig.NewAppLauncher()
+	if err = launcher.ParseApplicationArguments([]string{"-t", configuration}); err != nil {
+		t.Fatal(err)
+	}
+	diagnostics, err := launcher.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbconfig.HasErrors(diagnostics) {
+		logger.Errorf("Unexpected diagnostics %v", diagnostics)
+		t.Fail()
+	}
+	if _, err = os.Stat(marker); err == nil {
+		logger.Error("The validation ran the command")
+		t.Fail()
+	}
+}
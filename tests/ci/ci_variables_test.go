@@ -0,0 +1,68 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// TestVariables : the variables, facts and environment variables should be expanded in the check and load lines
+func TestVariables(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	myTests := []lbTest{
+		{title: "Constant", configurationContent: "set LOAD = 5\nload constant ${LOAD}", expectedMetricValue: 5},
+		{title: "NestedVariables", configurationContent: "set A=2\nset B = ${A}0\nset A = 3\nload constant ${B}",
+			expectedMetricValue: 20},
+		{title: "Environment", configurationContent: "load constant ${LBCLIENT_TEST_LOAD}", expectedMetricValue: 7,
+			setup:   func(t *testing.T) { _ = os.Setenv("LBCLIENT_TEST_LOAD", "7") },
+			cleanup: func(t *testing.T) { _ = os.Unsetenv("LBCLIENT_TEST_LOAD") }},
+		{title: "Hostname", configurationContent: "check command test ${HOSTNAME} = " + hostname + "\nload constant 4",
+			expectedMetricValue: 4},
+		{title: "EscapedDollar", configurationContent: "set PRICE = $$5\ncheck command test '${PRICE}' = '$'5\n" +
+			"load constant 3", expectedMetricValue: 3},
+		{title: "ShellReferences", configurationContent: "check command test \"${LBCLIENT_UNSET_VAR:-x}\" = x && " +
+			"test \"${LBCLIENT_UNSET_VAR}\" = \"\" && test $$ -gt 0\nload constant 3", expectedMetricValue: 3},
+		{title: "ShellReferencesValidation", configurationContent: "check command test \"${HOME:-/}\" != '' && " +
+			"echo $$ ${LBCLIENT_UNSET_VAR}\nload constant 3", expectedMetricValue: 3, validateConfig: true},
+		{title: "VariableInCommand", configurationContent: "set NAME = a\ncheck command test ${NAME} = a\n" +
+			"load constant 3", expectedMetricValue: 3},
+		{title: "UndefinedVariable", configurationContent: "load constant ${UNDEFINED_LOAD}",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "UsedBeforeDefinition", configurationContent: "load constant ${LOAD}\nset LOAD = 5",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+		{title: "InvalidDefinition", configurationContent: "set LOAD 5\nload constant 5",
+			expectedMetricValue: -1, shouldFail: true, validateConfig: true},
+	}
+
+	runMultipleTests(t, myTests)
+}
+
+// TestAliasVariable : the default configuration file should be evaluated for each alias when it uses the alias
+func TestAliasVariable(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	dir := createFiles(t, map[string]string{
+		"lbclient.conf": "check command test ${ALIAS} != b.cern.ch\nload constant 1",
+		"lbaliases":     "lbalias=a.cern.ch\nlbalias=b.cern.ch\nlbalias=c.cern.ch\n",
+	})
+	defer os.RemoveAll(dir)
+
+	launcher := lbconfig.NewAppLauncher()
+	if err := launcher.ParseApplicationArguments([]string{"--cm", dir, "--ca",
+		filepath.Join(dir, "lbaliases")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a.cern.ch=1,b.cern.ch=-14,c.cern.ch=1"
+	if launcher.MetricValue != expected {
+		logger.Errorf("Expected the output [%s] but got [%s]", expected, launcher.MetricValue)
+		t.Fail()
+	}
+}
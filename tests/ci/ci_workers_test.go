@@ -0,0 +1,103 @@
+package ci
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gitlab.cern.ch/lb-experts/golbclient/lbconfig"
+)
+
+// createAliasesLauncher : creates a launcher whose configuration directory contains one configuration file per alias
+func createAliasesLauncher(t *testing.T, configurations map[string]string, args ...string) (*lbconfig.AppLauncher,
+	string) {
+	dir, err := ioutil.TempDir("/tmp", "lbclient_workers_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var aliases string
+	for alias, content := range configurations {
+		aliases += fmt.Sprintf("lbalias=%s\n", alias)
+		if err = ioutil.WriteFile(filepath.Join(dir, "lbclient.conf."+alias), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "lbaliases"), []byte(aliases), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	launcher := lbconfig.NewAppLauncher()
+	args = append([]string{"--cm", dir, "--ca", filepath.Join(dir, "lbaliases")}, args...)
+	if err = launcher.ParseApplicationArguments(args); err != nil {
+		t.Fatal(err)
+	}
+	return launcher, dir
+}
+
+// TestConcurrentEvaluation : the configuration files should be evaluated concurrently, with a deterministic output
+func TestConcurrentEvaluation(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createAliasesLauncher(t, map[string]string{
+		"c.cern.ch": "check command sleep 0.5\nload constant 3",
+		"a.cern.ch": "check command sleep 0.5\nload constant 1",
+		"b.cern.ch": "check command sleep 0.5\nload constant 2",
+		"d.cern.ch": "check command false",
+	}, "--exec.workers", "4")
+	defer os.RemoveAll(dir)
+
+	start := time.Now()
+	if err := launcher.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 1200*time.Millisecond {
+		logger.Errorf("Expected the configuration files to be evaluated concurrently, but it took [%s]", elapsed)
+		t.Fail()
+	}
+	expected := "a.cern.ch=1,b.cern.ch=2,c.cern.ch=3,d.cern.ch=-14"
+	if launcher.MetricValue != expected {
+		logger.Errorf("Expected the output [%s] but got [%s]", expected, launcher.MetricValue)
+		t.Fail()
+	}
+}
+
+// TestConcurrentEvaluationErrors : the error of the last failing configuration file should still be returned
+func TestConcurrentEvaluationErrors(t *testing.T) {
+	logger.SetLevel(logger.FatalLevel)
+	launcher, dir := createAliasesLauncher(t, map[string]string{
+		"a.cern.ch": "bogus line",
+		"b.cern.ch": "load constant 2",
+		"c.cern.ch": "check command ../test/command/commandNotExecutable",
+	}, "--exec.workers", "2")
+	defer os.RemoveAll(dir)
+
+	err := launcher.Run()
+	if err == nil || err.Error() == "" {
+		t.Fatal("Expected the evaluation to fail")
+	}
+	if launcher.Mappings()[2].Err != err {
+		logger.Errorf("Expected the error of the last failing configuration file but got [%v]", err)
+		t.Fail()
+	}
+	expected := "a.cern.ch=-1,b.cern.ch=2,c.cern.ch=-14"
+	if launcher.MetricValue != expected {
+		logger.Errorf("Expected the output [%s] but got [%s]", expected, launcher.MetricValue)
+		t.Fail()
+	}
+}
+
+// TestInvalidWorkers : the non-positive amount of workers should be rejected with the arguments
+func TestInvalidWorkers(t *testing.T) {
+	for _, workers := range []string{"0", "-1"} {
+		launcher := lbconfig.NewAppLauncher()
+		err := launcher.ParseApplicationArguments([]string{"--exec.workers", workers})
+		if err == nil || !strings.Contains(err.Error(), "must be positive") {
+			logger.Errorf("Expected the amount of workers [%s] to be rejected but got the error [%v]", workers, err)
+			t.Fail()
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ci
 
 import (
+	"context"
 	"os"
 	"strconv"
 	"testing"
@@ -54,7 +55,7 @@ func runEOSCheck(t *testing.T, test eosTest) bool {
 	if test.timeout == 0 {
 		test.timeout = defaultTimeout
 	}
-	metricValue, err := checks.DoEOSCheck(f, testCmdBase, test.contextLogger)
+	metricValue, err := checks.DoEOSCheck(context.Background(), f, testCmdBase, test.contextLogger)
 	if test.cleanup != nil {
 		defer test.cleanup(t)
 	}